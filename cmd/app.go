@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/internal/domain/binders"
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/collections"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/groups"
+	"github.com/seosoojin/dalkom/internal/domain/idols"
+	"github.com/seosoojin/dalkom/internal/domain/search"
+	"github.com/seosoojin/dalkom/internal/domain/submissions"
+	"github.com/seosoojin/dalkom/internal/domain/users"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// app holds the database and repositories shared by the server and the
+// maintenance commands.
+type app struct {
+	db *mongo.Database
+
+	bindersRepo       binders.Repository
+	binderHistoryRepo binders.HistoryRepository
+	binderFolderRepo  binders.FolderRepository
+	cardsRepo         cards.Repository
+	usersRepo         users.Repository
+	idolRepo          idols.Repository
+	groupRepo         groups.Repository
+	collectionRepo    collections.Repository
+	submissionsRepo   submissions.Repository
+
+	searchIndex search.Index
+	deleter     deletion.Deleter
+}
+
+func newApp(ctx context.Context) (*app, error) {
+	if profile != "production" {
+		err := godotenv.Load(".env")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mongoURI := os.Getenv("MONGO_URI")
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		return nil, err
+	}
+
+	a := &app{
+		db: client.Database("dalkom"),
+	}
+
+	catalog := binders.Catalog{
+		Cards:       a.db.Collection("cards"),
+		Groups:      a.db.Collection("groups"),
+		Idols:       a.db.Collection("idols"),
+		Collections: a.db.Collection("collections"),
+	}
+
+	a.bindersRepo, err = binders.NewRepository(a.db.Collection("binders"), catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	a.binderHistoryRepo, err = wise.NewMongoSimpleRepository[models.BinderEvent](a.db.Collection("binder_history"))
+	if err != nil {
+		return nil, err
+	}
+
+	a.binderFolderRepo, err = wise.NewMongoSimpleRepository[models.BinderFolder](a.db.Collection("binder_folders"))
+	if err != nil {
+		return nil, err
+	}
+
+	a.cardsRepo, err = cards.NewRepository(catalog.Cards)
+	if err != nil {
+		return nil, err
+	}
+
+	a.usersRepo, err = wise.NewMongoSimpleRepository[models.User](a.db.Collection("users"))
+	if err != nil {
+		return nil, err
+	}
+
+	a.idolRepo, err = wise.NewMongoSimpleRepository[models.Idol](catalog.Idols)
+	if err != nil {
+		return nil, err
+	}
+
+	a.groupRepo, err = wise.NewMongoSimpleRepository[models.Group](catalog.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	a.collectionRepo, err = wise.NewMongoSimpleRepository[models.Collection](catalog.Collections)
+	if err != nil {
+		return nil, err
+	}
+
+	a.submissionsRepo, err = submissions.NewRepository(a.db.Collection("submissions"))
+	if err != nil {
+		return nil, err
+	}
+
+	a.searchIndex = search.NewIndex(a.cardsRepo, a.idolRepo, a.groupRepo, a.collectionRepo)
+	a.deleter = deletion.NewDeleter(a.cardsRepo, a.idolRepo, a.groupRepo, a.collectionRepo, binders.NewDetacher(a.bindersRepo, a.binderHistoryRepo), a.searchIndex)
+
+	return a, nil
+}
+
+func (a *app) cardsService() cards.Service {
+	return cards.NewService(a.cardsRepo, a.groupRepo, a.idolRepo, a.collectionRepo, a.searchIndex, a.deleter)
+}
+
+func (a *app) collectionsService() collections.Service {
+	return collections.NewService(a.collectionRepo, a.groupRepo, a.deleter, a.searchIndex)
+}
+
+func (a *app) bindersService() binders.Service {
+	return binders.NewService(a.bindersRepo, a.cardsRepo, a.collectionRepo, a.idolRepo, a.groupRepo, a.binderHistoryRepo, a.usersRepo, a.binderFolderRepo, a.cardsService(), a.collectionsService())
+}
+
+func (a *app) close(ctx context.Context) error {
+	return a.db.Client().Disconnect(ctx)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seosoojin/dalkom/internal/domain/binders"
+	"github.com/spf13/cobra"
+)
+
+var (
+	binderID     string
+	binderUserID string
+	binderFormat string
+	binderFile   string
+)
+
+var bindersCmd = &cobra.Command{
+	Use:   "binders",
+	Short: "Manage binders",
+}
+
+var bindersExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a binder as CSV or JSON",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer a.close(cmd.Context())
+
+		format, err := binders.ParseFormat(formatFor(binderFormat, binderFile))
+		if err != nil {
+			return err
+		}
+
+		userID, err := actingUser(cmd, a)
+		if err != nil {
+			return err
+		}
+
+		rows, err := a.bindersService().Export(cmd.Context(), userID, binderID)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if binderFile != "" {
+			f, err := os.Create(binderFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		return binders.EncodeRows(out, format, rows)
+	},
+}
+
+var bindersImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import CSV or JSON rows into a binder",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if binderFile == "" {
+			return errors.New("--file is required")
+		}
+
+		a, err := newApp(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer a.close(cmd.Context())
+
+		format, err := binders.ParseFormat(formatFor(binderFormat, binderFile))
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(binderFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		rows, err := binders.DecodeRows(f, format)
+		if err != nil {
+			return err
+		}
+
+		userID, err := actingUser(cmd, a)
+		if err != nil {
+			return err
+		}
+
+		report, err := a.bindersService().Import(cmd.Context(), userID, binderID, rows)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("imported %d, skipped %d, unmatched %d\n", report.Imported, len(report.Skipped), len(report.Unmatched))
+		for _, issue := range report.Skipped {
+			cmd.Printf("row %d skipped: %s\n", issue.Row, issue.Reason)
+		}
+		for _, issue := range report.Unmatched {
+			cmd.Printf("row %d unmatched: %s\n", issue.Row, issue.Reason)
+		}
+
+		return nil
+	},
+}
+
+// formatFor prefers an explicit format and otherwise guesses from the file
+// extension.
+func formatFor(format, file string) string {
+	if format != "" {
+		return format
+	}
+
+	return strings.TrimPrefix(filepath.Ext(file), ".")
+}
+
+// actingUser is the --user flag, defaulting to the binder owner since the CLI
+// already has direct database access.
+func actingUser(cmd *cobra.Command, a *app) (string, error) {
+	if binderUserID != "" {
+		return binderUserID, nil
+	}
+
+	binder, err := a.bindersRepo.FindOne(cmd.Context(), binderID)
+	if err != nil {
+		return "", err
+	}
+
+	return binder.UserID, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{bindersExportCmd, bindersImportCmd} {
+		c.Flags().StringVar(&binderID, "binder", "", "binder id")
+		c.Flags().StringVar(&binderUserID, "user", "", "acting user id (default is the binder owner)")
+		c.Flags().StringVar(&binderFormat, "format", "", "csv or json (default from the file extension, then json)")
+		c.Flags().StringVar(&binderFile, "file", "", "file to read or write")
+		c.MarkFlagRequired("binder")
+		bindersCmd.AddCommand(c)
+	}
+
+	rootCmd.AddCommand(bindersCmd)
+}
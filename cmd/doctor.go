@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/seosoojin/dalkom/internal/domain/integrity"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var doctorRepair bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Report references to deleted documents, optionally repairing them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer a.close(cmd.Context())
+
+		service := integrity.NewService(a.cardsRepo, a.idolRepo, a.groupRepo, a.collectionRepo, a.bindersRepo, a.searchIndex)
+
+		var report models.IntegrityReport
+		if doctorRepair {
+			report, err = service.Repair(cmd.Context())
+		} else {
+			report, err = service.Check(cmd.Context())
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range report.Issues {
+			cmd.Printf("%s %s: %s points at missing %s\n", issue.Resource, issue.ID, issue.Field, issue.Missing)
+		}
+
+		switch {
+		case len(report.Issues) == 0:
+			cmd.Println("no broken references found")
+		case doctorRepair:
+			cmd.Printf("%d issues, %d documents repaired\n", len(report.Issues), report.Repaired)
+		default:
+			cmd.Printf("%d issues, run with --repair to fix them\n", len(report.Issues))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorRepair, "repair", false, "clear broken references and remove deleted cards from binders")
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/seosoojin/dalkom/internal/domain/users"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	userEmail string
+	userRole  string
+)
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage users",
+}
+
+var usersGrantRoleCmd = &cobra.Command{
+	Use:   "grant-role",
+	Short: "Set the role of a user: admin, curator or member",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer a.close(cmd.Context())
+
+		user, err := users.NewService(a.usersRepo, nil).SetRole(cmd.Context(), userEmail, models.Role(userRole))
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("%s is now %s; the role applies to tokens issued from the next login\n", user.Email, user.Role)
+		return nil
+	},
+}
+
+func init() {
+	usersGrantRoleCmd.Flags().StringVar(&userEmail, "email", "", "email of the user")
+	usersGrantRoleCmd.Flags().StringVar(&userRole, "role", "", "admin, curator or member")
+	usersGrantRoleCmd.MarkFlagRequired("email")
+	usersGrantRoleCmd.MarkFlagRequired("role")
+	usersCmd.AddCommand(usersGrantRoleCmd)
+
+	rootCmd.AddCommand(usersCmd)
+}
@@ -82,9 +82,13 @@ func UserFromContext(ctx context.Context) *models.User {
 		return nil
 	}
 
+	// Tokens issued before roles existed carry none.
+	role, _ := userMap["role"].(string)
+
 	return &models.User{
 		ID:       userMap["id"].(string),
 		Email:    userMap["email"].(string),
 		Password: userMap["password"].(string),
+		Role:     models.Role(role),
 	}
 }
@@ -0,0 +1,33 @@
+package auth
+
+import "github.com/seosoojin/dalkom/pkg/models"
+
+type Permission string
+
+const (
+	PermissionEditCatalog     Permission = "catalog:edit"
+	PermissionDeleteCatalog   Permission = "catalog:delete"
+	PermissionModerateCatalog Permission = "catalog:moderate"
+)
+
+// rolePermissions lists what each role may do besides managing its own
+// binders, which every user can. Users without a role are members.
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleAdmin:   {PermissionEditCatalog, PermissionDeleteCatalog, PermissionModerateCatalog},
+	models.RoleCurator: {PermissionEditCatalog, PermissionModerateCatalog},
+	models.RoleMember:  {},
+}
+
+func Can(user *models.User, permission Permission) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, p := range rolePermissions[user.Role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
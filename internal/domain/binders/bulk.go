@@ -0,0 +1,272 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+const maxBulkOperations = 1000
+
+// errNothingApplied aborts a bulk write when every item failed.
+var errNothingApplied = errors.New("nothing applied")
+
+// Bulk applies many add, remove and move operations in order as a single
+// versioned write. Failing items are reported and skipped; the rest are
+// applied together or not at all.
+func (s *service) Bulk(ctx context.Context, userID, binderID string, ops []models.BulkOperation) (models.BulkReport, error) {
+	if len(ops) == 0 {
+		return models.BulkReport{}, ErrInvalidBulk
+	}
+
+	if len(ops) > maxBulkOperations {
+		return models.BulkReport{}, ErrTooManyOperations
+	}
+
+	known, err := s.knownCards(ctx, ops)
+	if err != nil {
+		return models.BulkReport{}, err
+	}
+
+	now := time.Now().UTC()
+	report := models.BulkReport{}
+
+	before, err := s.mutateEntries(ctx, userID, binderID, func(b models.Binder) ([]models.BinderEntry, error) {
+		report = models.BulkReport{Results: make([]models.BulkResult, 0, len(ops))}
+
+		entries := copyEntries(b.Entries)
+
+		for i, op := range ops {
+			result := models.BulkResult{Index: i, Op: op.Op, CardID: op.CardID}
+
+			next, err := applyBulkOperation(b, entries, op, known, now)
+			if err != nil {
+				result.Error = err.Error()
+				report.Failed++
+			} else {
+				entries = next
+				result.OK = true
+				report.Applied++
+			}
+
+			report.Results = append(report.Results, result)
+		}
+
+		if report.Applied == 0 {
+			return nil, errNothingApplied
+		}
+
+		return entries, nil
+	})
+	if errors.Is(err, errNothingApplied) {
+		return report, nil
+	}
+
+	if err != nil {
+		return models.BulkReport{}, err
+	}
+
+	if err := s.record(ctx, userID, binderID, models.BinderEventBulk, &before); err != nil {
+		return models.BulkReport{}, err
+	}
+
+	return report, nil
+}
+
+// knownCards returns which of the cards added by ops exist in the catalog.
+func (s *service) knownCards(ctx context.Context, ops []models.BulkOperation) (map[string]struct{}, error) {
+	ids := []string{}
+	for _, op := range ops {
+		if op.Op == models.BulkOpAdd && op.CardID != "" {
+			ids = append(ids, op.CardID)
+		}
+	}
+
+	known := make(map[string]struct{}, len(ids))
+	if len(ids) == 0 {
+		return known, nil
+	}
+
+	cards, err := s.cardRepo.Find(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range cards {
+		known[c.ID] = struct{}{}
+	}
+
+	return known, nil
+}
+
+func applyBulkOperation(binder models.Binder, entries []models.BinderEntry, op models.BulkOperation, known map[string]struct{}, now time.Time) ([]models.BinderEntry, error) {
+	layout := binder.Layout()
+
+	if op.CardID == "" {
+		return nil, ErrInvalidEntry
+	}
+
+	switch op.Op {
+	case models.BulkOpAdd:
+		entry := op.BinderEntry
+		if entry.Quantity == 0 {
+			entry.Quantity = 1
+		}
+
+		if err := validateEntry(entry); err != nil {
+			return nil, err
+		}
+
+		if _, ok := known[entry.CardID]; !ok {
+			return nil, ErrUnknownCard
+		}
+
+		if findEntry(entries, entry.CardID) >= 0 {
+			return nil, ErrCardAlreadyInBinder
+		}
+
+		entry.AddedAt = now
+		if entry.Position == nil {
+			entry.Position = placeholderSlot(binder, entries, entry.CardID)
+		}
+
+		next := append(copyEntries(entries), entry)
+		if err := validateLayout(layout, next); err != nil {
+			return nil, err
+		}
+
+		return next, nil
+	case models.BulkOpRemove:
+		i := findEntry(entries, op.CardID)
+		if i < 0 {
+			return nil, ErrCardNotInBinder
+		}
+
+		next := copyEntries(entries)
+		return append(next[:i], next[i+1:]...), nil
+	case models.BulkOpMove:
+		if op.Position != nil {
+			return moveCard(layout, entries, op.CardID, *op.Position)
+		}
+
+		i := findEntry(entries, op.CardID)
+		if i < 0 {
+			return nil, ErrCardNotInBinder
+		}
+
+		next := copyEntries(entries)
+		next[i].Position = nil
+		return next, nil
+	default:
+		return nil, ErrInvalidBulk
+	}
+}
+
+// TransferCards moves cards with their metadata from one binder to another.
+// Cards take their placeholder pocket in the target when it has one and arrive
+// loose otherwise, since the binders may use different layouts. The target
+// is written first; if the source cannot be updated afterwards the target is
+// rolled back.
+func (s *service) TransferCards(ctx context.Context, userID, fromID, toID string, cardIDs []string) (models.BulkReport, error) {
+	if len(cardIDs) == 0 {
+		return models.BulkReport{}, ErrInvalidBulk
+	}
+
+	if len(cardIDs) > maxBulkOperations {
+		return models.BulkReport{}, ErrTooManyOperations
+	}
+
+	if fromID == toID {
+		return models.BulkReport{}, ErrSameBinder
+	}
+
+	source, err := s.authorize(ctx, Actor{UserID: userID}, fromID, ActionUpdate)
+	if err != nil {
+		return models.BulkReport{}, err
+	}
+
+	if err := notSmart(source); err != nil {
+		return models.BulkReport{}, err
+	}
+
+	report := models.BulkReport{}
+	moved := map[string]struct{}{}
+
+	target, err := s.mutateEntries(ctx, userID, toID, func(b models.Binder) ([]models.BinderEntry, error) {
+		report = models.BulkReport{Results: make([]models.BulkResult, 0, len(cardIDs))}
+		moved = map[string]struct{}{}
+
+		entries := copyEntries(b.Entries)
+
+		for i, cardID := range cardIDs {
+			result := models.BulkResult{Index: i, Op: models.BulkOpMove, CardID: cardID}
+
+			j := findEntry(source.Entries, cardID)
+			switch {
+			case j < 0:
+				result.Error = ErrCardNotInBinder.Error()
+			case findEntry(entries, cardID) >= 0:
+				result.Error = ErrCardAlreadyInBinder.Error()
+			default:
+				entry := source.Entries[j]
+				entry.Position = placeholderSlot(b, entries, cardID)
+				entries = append(entries, entry)
+				moved[cardID] = struct{}{}
+				result.OK = true
+			}
+
+			if result.OK {
+				report.Applied++
+			} else {
+				report.Failed++
+			}
+
+			report.Results = append(report.Results, result)
+		}
+
+		if report.Applied == 0 {
+			return nil, errNothingApplied
+		}
+
+		return entries, nil
+	})
+	if errors.Is(err, errNothingApplied) {
+		return report, nil
+	}
+
+	if err != nil {
+		return models.BulkReport{}, err
+	}
+
+	without := func(b models.Binder) ([]models.BinderEntry, error) {
+		entries := make([]models.BinderEntry, 0, len(b.Entries))
+		for _, e := range copyEntries(b.Entries) {
+			if _, ok := moved[e.CardID]; !ok {
+				entries = append(entries, e)
+			}
+		}
+
+		return entries, nil
+	}
+
+	before, err := s.mutateEntries(ctx, userID, fromID, without)
+	if err != nil {
+		if _, rollbackErr := s.mutateEntries(ctx, userID, toID, without); rollbackErr != nil {
+			return models.BulkReport{}, errors.Join(err, rollbackErr)
+		}
+
+		return models.BulkReport{}, err
+	}
+
+	if err := s.record(ctx, userID, toID, models.BinderEventTransfer, &target); err != nil {
+		return models.BulkReport{}, err
+	}
+
+	if err := s.record(ctx, userID, fromID, models.BinderEventTransfer, &before); err != nil {
+		return models.BulkReport{}, err
+	}
+
+	return report, nil
+}
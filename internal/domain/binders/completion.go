@@ -0,0 +1,205 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *service) GetBinderCompletion(ctx context.Context, userID, binderID, collectionID string, variants models.VariantCounting) (models.Completion, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionRead)
+	if err != nil {
+		return models.Completion{}, err
+	}
+
+	return s.completion(ctx, collectionID, []models.Binder{binder}, variants)
+}
+
+func (s *service) GetUserCompletion(ctx context.Context, userID, collectionID string, variants models.VariantCounting) (models.Completion, error) {
+	binders, err := s.repo.Search(ctx, map[string][]any{"user_id": {userID}})
+	if err != nil {
+		return models.Completion{}, err
+	}
+
+	for i := range binders {
+		binders[i].MigrateLegacyCardIDs()
+	}
+
+	return s.completion(ctx, collectionID, binders, variants)
+}
+
+func (s *service) completion(ctx context.Context, collectionID string, binders []models.Binder, variants models.VariantCounting) (models.Completion, error) {
+	if variants == "" {
+		variants = models.VariantCountingEach
+	}
+
+	if variants != models.VariantCountingEach && variants != models.VariantCountingAny && variants != models.VariantCountingIgnore {
+		return models.Completion{}, ErrInvalidVariants
+	}
+
+	collection, err := s.collectionRepo.FindOne(ctx, collectionID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Completion{}, ErrCollectionNotFound
+	}
+
+	if err != nil {
+		return models.Completion{}, err
+	}
+
+	cards, err := s.cardRepo.Search(ctx, map[string][]any{"collection_id": {collectionID}})
+	if err != nil {
+		return models.Completion{}, err
+	}
+
+	idolIDs := []string{}
+	seen := map[string]struct{}{}
+	for _, card := range cards {
+		for _, id := range card.IdolIDs {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			idolIDs = append(idolIDs, id)
+		}
+	}
+
+	idols, err := s.idolRepo.Find(ctx, idolIDs)
+	if err != nil {
+		return models.Completion{}, err
+	}
+
+	owned := map[string]struct{}{}
+	for _, binder := range binders {
+		entries, err := s.resolveEntries(ctx, binder)
+		if err != nil {
+			return models.Completion{}, err
+		}
+
+		for _, entry := range entries {
+			owned[entry.CardID] = struct{}{}
+		}
+	}
+
+	if variants != models.VariantCountingEach {
+		cards = countVariants(cards, owned, variants)
+	}
+
+	result := computeCompletion(collection, cards, idols, owned)
+	result.Variants = variants
+
+	return result, nil
+}
+
+// countVariants leaves only the base cards to complete. With
+// VariantCountingAny an owned variant marks its base card as owned.
+func countVariants(cards []models.Card, owned map[string]struct{}, variants models.VariantCounting) []models.Card {
+	bases := make([]models.Card, 0, len(cards))
+
+	for _, card := range cards {
+		if !card.IsVariant() {
+			bases = append(bases, card)
+			continue
+		}
+
+		if _, ok := owned[card.ID]; ok && variants == models.VariantCountingAny {
+			owned[card.BaseCardID] = struct{}{}
+		}
+	}
+
+	return bases
+}
+
+func computeCompletion(collection models.Collection, cards []models.Card, idols []models.Idol, owned map[string]struct{}) models.Completion {
+	result := models.Completion{
+		Collection:   collection,
+		OwnedCards:   []models.Card{},
+		MissingCards: []models.Card{},
+		ByIdol:       []models.CompletionBreakdown{},
+		ByType:       []models.CompletionBreakdown{},
+	}
+
+	idolNames := make(map[string]string, len(idols))
+	for _, idol := range idols {
+		idolNames[idol.ID] = idol.StageName
+	}
+
+	byIdol := map[string]*models.CompletionBreakdown{}
+	byType := map[models.CardType]*models.CompletionBreakdown{}
+
+	for _, card := range cards {
+		_, isOwned := owned[card.ID]
+
+		result.Total++
+		if isOwned {
+			result.Owned++
+			result.OwnedCards = append(result.OwnedCards, card)
+		} else {
+			result.MissingCards = append(result.MissingCards, card)
+		}
+
+		for _, idolID := range card.IdolIDs {
+			b, ok := byIdol[idolID]
+			if !ok {
+				b = &models.CompletionBreakdown{Key: idolID, Label: idolNames[idolID]}
+				byIdol[idolID] = b
+			}
+			countInto(b, isOwned)
+		}
+
+		b, ok := byType[card.Type]
+		if !ok {
+			label := models.ShortTypesMap[card.Type]
+			if label == "" {
+				label = string(card.Type)
+			}
+			b = &models.CompletionBreakdown{Key: string(card.Type), Label: label}
+			byType[card.Type] = b
+		}
+		countInto(b, isOwned)
+	}
+
+	result.Percentage = percentage(result.Owned, result.Total)
+
+	for _, b := range byIdol {
+		b.Percentage = percentage(b.Owned, b.Total)
+		result.ByIdol = append(result.ByIdol, *b)
+	}
+
+	sort.Slice(result.ByIdol, func(i, j int) bool {
+		return result.ByIdol[i].Label < result.ByIdol[j].Label
+	})
+
+	for _, t := range models.CardTypes {
+		if b, ok := byType[t]; ok {
+			b.Percentage = percentage(b.Owned, b.Total)
+			result.ByType = append(result.ByType, *b)
+			delete(byType, t)
+		}
+	}
+
+	for _, b := range byType {
+		b.Percentage = percentage(b.Owned, b.Total)
+		result.ByType = append(result.ByType, *b)
+	}
+
+	return result
+}
+
+func countInto(b *models.CompletionBreakdown, owned bool) {
+	b.Total++
+	if owned {
+		b.Owned++
+	}
+}
+
+func percentage(owned, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return math.Round(float64(owned)/float64(total)*10000) / 100
+}
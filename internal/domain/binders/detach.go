@@ -0,0 +1,59 @@
+package binders
+
+import (
+	"context"
+	"errors"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Detacher takes deleted cards out of binders, recording a detach event in
+// the history of every binder it changes. The events carry no user: the
+// catalog changed, not the binder.
+type Detacher struct {
+	repo        Repository
+	historyRepo HistoryRepository
+}
+
+func NewDetacher(repo Repository, historyRepo HistoryRepository) *Detacher {
+	return &Detacher{
+		repo:        repo,
+		historyRepo: historyRepo,
+	}
+}
+
+func (d *Detacher) HoldingCards(ctx context.Context, cardIDs []string) ([]string, error) {
+	return d.repo.HoldingCards(ctx, cardIDs)
+}
+
+// DetachCards removes the cards from every binder holding them and returns
+// how many binders changed.
+func (d *Detacher) DetachCards(ctx context.Context, cardIDs []string) (int64, error) {
+	binderIDs, err := d.repo.HoldingCards(ctx, cardIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	var detached int64
+	for _, binderID := range binderIDs {
+		before, err := d.repo.FindOne(ctx, binderID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return detached, err
+		}
+
+		if err := d.repo.DetachCardsFrom(ctx, binderID, cardIDs); err != nil {
+			return detached, err
+		}
+
+		if err := recordEvent(ctx, d.repo, d.historyRepo, "", binderID, models.BinderEventDetach, &before); err != nil {
+			return detached, err
+		}
+		detached++
+	}
+
+	return detached, nil
+}
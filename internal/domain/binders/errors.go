@@ -0,0 +1,40 @@
+package binders
+
+import "errors"
+
+var (
+	ErrNotFound            = errors.New("binder not found")
+	ErrForbidden           = errors.New("forbidden")
+	ErrCardAlreadyInBinder = errors.New("card already in binder")
+	ErrCardNotInBinder     = errors.New("card not in binder")
+	ErrInvalidEntry        = errors.New("invalid binder entry")
+	ErrInvalidType         = errors.New("invalid binder type")
+	ErrInvalidPosition     = errors.New("invalid slot position")
+	ErrSlotOccupied        = errors.New("slot already occupied")
+	ErrConflict            = errors.New("binder was modified concurrently")
+	ErrCollectionNotFound  = errors.New("collection not found")
+	ErrInvalidVisibility   = errors.New("invalid binder visibility")
+	ErrShareTokenNotFound  = errors.New("share token not found")
+	ErrInvalidQuery        = errors.New("invalid smart binder query")
+	ErrSmartBinder         = errors.New("smart binder contents follow its query; convert it to a static binder first")
+	ErrNotSmartBinder      = errors.New("binder is not a smart binder")
+	ErrInvalidUndo         = errors.New("undo count must be at least 1")
+	ErrNoHistory           = errors.New("not enough binder history")
+	ErrInvalidMember       = errors.New("invalid binder member")
+	ErrMemberNotFound      = errors.New("binder member not found")
+	ErrInvalidFormat       = errors.New("format must be csv or json")
+	ErrInvalidImport       = errors.New("invalid import file")
+	ErrInvalidBulk         = errors.New("invalid bulk request")
+	ErrTooManyRows         = errors.New("too many rows in import file")
+	ErrTooManyOperations   = errors.New("too many operations in one request")
+	ErrUnknownCard         = errors.New("card does not exist")
+	ErrSameBinder          = errors.New("source and target binder are the same")
+	ErrFolderNotFound      = errors.New("folder not found")
+	ErrInvalidFolder       = errors.New("invalid folder")
+	ErrInvalidTag          = errors.New("invalid tag")
+	ErrInvalidSort         = errors.New("invalid sort field")
+	ErrInvalidOrder        = errors.New("invalid shelf order")
+	ErrPageNotFound        = errors.New("binder page not found")
+	ErrTooManyPages        = errors.New("binder has too many pages to render at once; render it page by page")
+	ErrInvalidVariants     = errors.New("variants must be each, any or ignore")
+)
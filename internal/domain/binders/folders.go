@@ -0,0 +1,295 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 32
+)
+
+// ListOptions controls how a user's shelf is listed. Sort is one of the keys
+// of sortFields; Recursive makes a folder_id filter include subfolders.
+type ListOptions struct {
+	Sort      string
+	Desc      bool
+	Recursive bool
+}
+
+var sortFields = map[string]string{
+	"shelf":    "shelf_position",
+	"name":     "name",
+	"folder":   "folder_id",
+	"tags":     "tags",
+	"favorite": "is_favorite",
+	"type":     "type",
+}
+
+func sortOption(opts ListOptions) (map[string]int, error) {
+	if opts.Sort == "" {
+		opts.Sort = "shelf"
+	}
+
+	field, ok := sortFields[opts.Sort]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+
+	direction := 1
+	if opts.Desc {
+		direction = -1
+	}
+
+	return map[string]int{field: direction}, nil
+}
+
+// normalizeTags trims tags and drops blanks and case insensitive duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTag
+		}
+
+		key := strings.ToLower(tag)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		out = append(out, tag)
+	}
+
+	if len(out) > maxTags {
+		return nil, ErrInvalidTag
+	}
+
+	return out, nil
+}
+
+func (s *service) SetTags(ctx context.Context, userID, binderID string, tags []string) ([]string, error) {
+	before, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionOrganize)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetTags(ctx, binderID, tags); err != nil {
+		return nil, err
+	}
+
+	return tags, s.record(ctx, userID, binderID, models.BinderEventUpdate, &before)
+}
+
+func (s *service) SetFolder(ctx context.Context, userID, binderID, folderID string) error {
+	before, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionOrganize)
+	if err != nil {
+		return err
+	}
+
+	if folderID != "" {
+		if _, err := s.folder(ctx, userID, folderID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.SetFolder(ctx, binderID, folderID); err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventUpdate, &before)
+}
+
+// ReorderShelf stores the order of the user's binders. Binders left out keep
+// their position; binders the user does not own are ignored.
+func (s *service) ReorderShelf(ctx context.Context, userID string, binderIDs []string) error {
+	if len(binderIDs) == 0 {
+		return ErrInvalidOrder
+	}
+
+	seen := make(map[string]struct{}, len(binderIDs))
+	for _, id := range binderIDs {
+		if _, ok := seen[id]; ok || id == "" {
+			return ErrInvalidOrder
+		}
+		seen[id] = struct{}{}
+	}
+
+	return s.repo.SetShelfOrder(ctx, userID, binderIDs)
+}
+
+func (s *service) GetFolders(ctx context.Context, userID string) ([]models.BinderFolder, error) {
+	return s.folderRepo.Search(ctx, map[string][]any{"user_id": {userID}})
+}
+
+func (s *service) CreateFolder(ctx context.Context, userID string, folder *models.BinderFolder) error {
+	folder.Name = strings.TrimSpace(folder.Name)
+	if folder.Name == "" {
+		return ErrInvalidFolder
+	}
+
+	if folder.ParentID != "" {
+		if _, err := s.folder(ctx, userID, folder.ParentID); err != nil {
+			return err
+		}
+	}
+
+	folder.ID = uuid.NewString()
+	folder.UserID = userID
+
+	return s.folderRepo.Upsert(ctx, folder.ID, *folder)
+}
+
+// UpdateFolder renames a folder or moves it under another parent, refusing
+// moves that would put a folder inside itself.
+func (s *service) UpdateFolder(ctx context.Context, userID string, folder *models.BinderFolder) error {
+	current, err := s.folder(ctx, userID, folder.ID)
+	if err != nil {
+		return err
+	}
+
+	folder.Name = strings.TrimSpace(folder.Name)
+	if folder.Name == "" {
+		folder.Name = current.Name
+	}
+
+	if folder.ParentID != "" {
+		folders, err := s.GetFolders(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		parents := make(map[string]string, len(folders))
+		for _, f := range folders {
+			parents[f.ID] = f.ParentID
+		}
+
+		if _, ok := parents[folder.ParentID]; !ok {
+			return ErrFolderNotFound
+		}
+
+		for id := folder.ParentID; id != ""; id = parents[id] {
+			if id == folder.ID {
+				return ErrInvalidFolder
+			}
+		}
+	}
+
+	folder.UserID = userID
+
+	return s.folderRepo.Upsert(ctx, folder.ID, *folder)
+}
+
+// DeleteFolder removes a folder, moving its binders and subfolders up to its
+// parent.
+func (s *service) DeleteFolder(ctx context.Context, userID, folderID string) (models.BinderFolder, error) {
+	folder, err := s.folder(ctx, userID, folderID)
+	if err != nil {
+		return models.BinderFolder{}, err
+	}
+
+	children, err := s.folderRepo.Search(ctx, map[string][]any{"user_id": {userID}, "parent_id": {folderID}})
+	if err != nil {
+		return models.BinderFolder{}, err
+	}
+
+	for _, child := range children {
+		child.ParentID = folder.ParentID
+		if err := s.folderRepo.Upsert(ctx, child.ID, child); err != nil {
+			return models.BinderFolder{}, err
+		}
+	}
+
+	if err := s.repo.MoveFolderContents(ctx, userID, folderID, folder.ParentID); err != nil {
+		return models.BinderFolder{}, err
+	}
+
+	return s.folderRepo.Delete(ctx, folderID)
+}
+
+func (s *service) folder(ctx context.Context, userID, folderID string) (models.BinderFolder, error) {
+	folder, err := s.folderRepo.FindOne(ctx, folderID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.BinderFolder{}, ErrFolderNotFound
+	}
+
+	if err != nil {
+		return models.BinderFolder{}, err
+	}
+
+	if folder.UserID != userID {
+		return models.BinderFolder{}, ErrFolderNotFound
+	}
+
+	return folder, nil
+}
+
+// folderFilter turns the folder_id values of a listing into the ids to match.
+// An empty value stands for binders outside any folder; recursive listings
+// also match every folder below the requested ones.
+func (s *service) folderFilter(ctx context.Context, userID string, values []any, recursive bool) ([]any, error) {
+	ids := []any{}
+	roots := []string{}
+
+	for _, v := range values {
+		id, _ := v.(string)
+		if id == "" {
+			ids = append(ids, "", nil)
+			continue
+		}
+
+		ids = append(ids, id)
+		roots = append(roots, id)
+	}
+
+	if !recursive || len(roots) == 0 {
+		return ids, nil
+	}
+
+	folders, err := s.GetFolders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := map[string][]string{}
+	for _, f := range folders {
+		children[f.ParentID] = append(children[f.ParentID], f.ID)
+	}
+
+	seen := map[string]struct{}{}
+	for len(roots) > 0 {
+		id := roots[0]
+		roots = roots[1:]
+
+		for _, child := range children[id] {
+			if _, ok := seen[child]; ok {
+				continue
+			}
+			seen[child] = struct{}{}
+
+			ids = append(ids, child)
+			roots = append(roots, child)
+		}
+	}
+
+	return ids, nil
+}
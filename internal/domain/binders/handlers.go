@@ -2,13 +2,20 @@ package binders
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/filters"
 	"github.com/seosoojin/dalkom/internal/domain/handlers"
 	"github.com/seosoojin/dalkom/internal/domain/pagination"
 	"github.com/seosoojin/dalkom/internal/gateways/middlewares"
@@ -26,32 +33,88 @@ type Handler interface {
 
 type handler struct {
 	service        Service
+	renderer       Renderer
 	authMiddleware middlewares.Authenticator
 }
 
 var _ Handler = &handler{}
 
-func NewHandler(service Service, auth middlewares.Authenticator) *handler {
+func NewHandler(service Service, renderer Renderer, auth middlewares.Authenticator) *handler {
 	return &handler{
 		service:        service,
+		renderer:       renderer,
 		authMiddleware: auth,
 	}
 }
 
+type TagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type FolderRequest struct {
+	FolderID string `json:"folder_id"`
+}
+
+type ShelfOrderRequest struct {
+	BinderIDs []string `json:"binder_ids"`
+}
+
+type SlotRequest struct {
+	CardID string               `json:"card_id"`
+	From   *models.SlotPosition `json:"from"`
+	To     *models.SlotPosition `json:"to"`
+}
+
 func (h *handler) RegisterRoutes(r *chi.Mux) {
-	// r.Get("/users/{id}/binders", h.GetByUserID)
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Identify())
+		r.Get("/users/{id}/binders", h.GetPublicByUserID)
+		r.Get("/shared/{token}", h.GetShared)
+		r.Get("/shared/{token}/cards", h.GetSharedCards)
+		r.Get("/binders/{id}/cards", h.GetBinderCards)
+		r.Get("/binders/{id}/pages", h.GetBinderPages)
+		r.Get("/binders/{id}/pages/{page}/image", h.RenderPage)
+		r.Get("/binders/{id}/image", h.RenderBinder)
+		r.Get("/binders/{id}/collections/{collection_id}/completion", h.GetBinderCompletion)
+		r.Get("/binders/{id}/stats", h.GetBinderStats)
+		r.Get("/binders/{id}", h.GetByID)
+	})
+
 	r.Group(func(r chi.Router) {
 		r.Use(h.authMiddleware.Authenticate())
 		r.Get("/me/binders", h.GetByUserID)
-		r.Get("/binders/{id}/cards", h.GetBinderCards)
+		r.Put("/me/binders/order", h.ReorderShelf)
+		r.Get("/me/folders", h.GetFolders)
+		r.Post("/me/folders", h.CreateFolder)
+		r.Put("/me/folders/{id}", h.UpdateFolder)
+		r.Delete("/me/folders/{id}", h.DeleteFolder)
+		r.Get("/me/collections/{collection_id}/completion", h.GetUserCompletion)
+		r.Get("/me/stats", h.GetUserStats)
 		r.Post("/binders", h.Create)
+		r.Post("/binders/templates", h.CreateFromTemplate)
 		r.Put("/binders/{id}", h.Update)
+		r.Put("/binders/{id}/tags", h.SetTags)
+		r.Put("/binders/{id}/folder", h.SetFolder)
 		r.Patch("/binders/{id}/cards/{card_id}", h.AddCard)
+		r.Put("/binders/{id}/cards/{card_id}", h.UpdateCard)
 		r.Delete("/binders/{id}/cards/{card_id}", h.RemoveCard)
-		r.Get("/binders/{id}", h.GetByID)
+		r.Post("/binders/{id}/cards/bulk", h.Bulk)
+		r.Post("/binders/{id}/cards/transfer", h.TransferCards)
+		r.Post("/binders/{id}/slots/move", h.MoveCard)
+		r.Post("/binders/{id}/slots/swap", h.SwapSlots)
+		r.Post("/binders/{id}/slots/insert", h.InsertCard)
+		r.Post("/binders/{id}/convert-to-static", h.ConvertToStatic)
+		r.Post("/binders/{id}/share-tokens", h.CreateShareToken)
+		r.Delete("/binders/{id}/share-tokens/{token}", h.RevokeShareToken)
+		r.Post("/binders/{id}/members", h.AddMember)
+		r.Delete("/binders/{id}/members/{user_id}", h.RemoveMember)
+		r.Get("/binders/{id}/export", h.Export)
+		r.Post("/binders/{id}/import", h.Import)
+		r.Get("/binders/{id}/history", h.GetHistory)
+		r.Post("/binders/{id}/undo", h.Undo)
+		r.Post("/binders/{id}/restore", h.Restore)
 		r.Delete("/binders/{id}", h.Delete)
 	})
-
 }
 
 func (h *handler) GetByUserID(w http.ResponseWriter, r *http.Request) {
@@ -63,29 +126,81 @@ func (h *handler) GetByUserID(w http.ResponseWriter, r *http.Request) {
 
 	user := auth.UserFromContext(r.Context())
 
-	filter := h.parseFilter(r)
+	filter, err := h.parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := ListOptions{
+		Sort:      r.URL.Query().Get("sort"),
+		Desc:      r.URL.Query().Get("order") == "desc",
+		Recursive: r.URL.Query().Get("recursive") == "true",
+	}
 
-	binders, err := h.service.GetByUserID(r.Context(), user.ID, filter, page)
+	binders, err := h.service.GetByUserID(r.Context(), user.ID, filter, opts, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
 	render.JSON(w, r, binders)
 }
 
-func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	binder, err := h.service.GetByID(r.Context(), id)
+func (h *handler) GetPublicByUserID(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.NewPageFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	user := auth.UserFromContext(r.Context())
+	ownerID := chi.URLParam(r, "id")
+
+	binders, err := h.service.GetPublicByUserID(r.Context(), ownerID, page)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binders)
+}
+
+func (h *handler) GetShared(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	binder, err := h.service.GetShared(r.Context(), token)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binder)
+}
+
+func (h *handler) GetSharedCards(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	expand, err := cards.NewExpandFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	binderCards, err := h.service.GetSharedCards(r.Context(), token, expand)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binderCards)
+}
+
+func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
 
-	if binder.UserID != user.ID {
-		http.Error(w, "unauthorized", http.StatusForbidden)
+	binder, err := h.service.GetByID(r.Context(), userID(r), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
@@ -93,28 +208,129 @@ func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) GetBinderCards(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	expand, err := cards.NewExpandFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
+	binderCards, err := h.service.GetBinderCards(r.Context(), userID(r), id, expand)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binderCards)
+}
+
+func (h *handler) GetBinderPages(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	binder, err := h.service.GetByID(r.Context(), id)
+
+	expand, err := cards.NewExpandFromRequest(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pages, err := h.service.GetBinderPages(r.Context(), userID(r), id, expand)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
+	render.JSON(w, r, pages)
+}
+
+func (h *handler) RenderPage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	page, err := strconv.Atoi(chi.URLParam(r, "page"))
+	if err != nil {
+		http.Error(w, "page must be a number", http.StatusBadRequest)
+		return
+	}
+
+	out, err := h.renderer.RenderPage(r.Context(), userID(r), id, page)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	writePNG(w, out)
+}
+
+func (h *handler) RenderBinder(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	out, err := h.renderer.RenderBinder(r.Context(), userID(r), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	writePNG(w, out)
+}
+
+func writePNG(w http.ResponseWriter, out []byte) {
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(out)))
+	w.Write(out)
+}
+
+func (h *handler) GetBinderCompletion(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	collectionID := chi.URLParam(r, "collection_id")
+
+	variants := models.VariantCounting(r.URL.Query().Get("variants"))
+
+	completion, err := h.service.GetBinderCompletion(r.Context(), userID(r), id, collectionID, variants)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, completion)
+}
+
+func (h *handler) GetUserCompletion(w http.ResponseWriter, r *http.Request) {
+	collectionID := chi.URLParam(r, "collection_id")
 	user := auth.UserFromContext(r.Context())
 
-	if binder.UserID != user.ID {
-		http.Error(w, "unauthorized", http.StatusForbidden)
+	variants := models.VariantCounting(r.URL.Query().Get("variants"))
+
+	completion, err := h.service.GetUserCompletion(r.Context(), user.ID, collectionID, variants)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, completion)
+}
+
+func (h *handler) GetBinderStats(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	stats, err := h.service.GetBinderStats(r.Context(), userID(r), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
-	cards, err := h.service.GetBinderCards(r.Context(), id)
+	render.JSON(w, r, stats)
+}
+
+func (h *handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	stats, err := h.service.GetUserStats(r.Context(), user.ID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
-	render.JSON(w, r, cards)
+	render.JSON(w, r, stats)
 }
 
 func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
@@ -133,16 +349,149 @@ func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := auth.UserFromContext(r.Context())
-	binder.UserID = user.ID
 
-	if err := h.service.Create(r.Context(), binder); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.Create(r.Context(), user.ID, binder); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binder)
+}
+
+func (h *handler) CreateFromTemplate(w http.ResponseWriter, r *http.Request) {
+	template := new(models.BinderTemplate)
+	if err := json.NewDecoder(r.Body).Decode(template); err != nil || template.CollectionID == "" {
+		http.Error(w, "collection_id is required", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	binder, err := h.service.CreateFromTemplate(r.Context(), user.ID, *template)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
 	render.JSON(w, r, binder)
 }
 
+func (h *handler) SetTags(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	req := new(TagsRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	tags, err := h.service.SetTags(r.Context(), user.ID, binderID, req.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, TagsRequest{Tags: tags})
+}
+
+func (h *handler) SetFolder(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	req := new(FolderRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.SetFolder(r.Context(), user.ID, binderID, req.FolderID); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) ReorderShelf(w http.ResponseWriter, r *http.Request) {
+	req := new(ShelfOrderRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.ReorderShelf(r.Context(), user.ID, req.BinderIDs); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) GetFolders(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	folders, err := h.service.GetFolders(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, folders)
+}
+
+func (h *handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	folder := new(models.BinderFolder)
+	if err := json.NewDecoder(r.Body).Decode(folder); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.CreateFolder(r.Context(), user.ID, folder); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, folder)
+}
+
+func (h *handler) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	folder := new(models.BinderFolder)
+	if err := json.NewDecoder(r.Body).Decode(folder); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	folder.ID = chi.URLParam(r, "id")
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.UpdateFolder(r.Context(), user.ID, folder); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, folder)
+}
+
+func (h *handler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	folderID := chi.URLParam(r, "id")
+	user := auth.UserFromContext(r.Context())
+
+	folder, err := h.service.DeleteFolder(r.Context(), user.ID, folderID)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, folder)
+}
+
 func (h *handler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	binder := new(models.Binder)
@@ -153,9 +502,11 @@ func (h *handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user := auth.UserFromContext(r.Context())
+
 	binder.ID = id
-	if err := h.service.Update(r.Context(), binder); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.Update(r.Context(), user.ID, binder); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
@@ -164,22 +515,195 @@ func (h *handler) Update(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) AddCard(w http.ResponseWriter, r *http.Request) {
 	binderID := chi.URLParam(r, "id")
-	cardID := chi.URLParam(r, "card_id")
 
-	if err := h.service.AddCard(r.Context(), binderID, cardID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	entry, err := h.parseEntry(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	render.JSON(w, r, nil)
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.AddCard(r.Context(), user.ID, binderID, entry); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, entry)
+}
+
+func (h *handler) UpdateCard(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	entry, err := h.parseEntry(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.UpdateCard(r.Context(), user.ID, binderID, entry); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, entry)
 }
 
 func (h *handler) RemoveCard(w http.ResponseWriter, r *http.Request) {
 	binderID := chi.URLParam(r, "id")
 	cardID := chi.URLParam(r, "card_id")
+	user := auth.UserFromContext(r.Context())
 
-	if err := h.service.RemoveCard(r.Context(), binderID, cardID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.RemoveCard(r.Context(), user.ID, binderID, cardID); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, nil)
+}
+
+func (h *handler) Bulk(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	ops := []models.BulkOperation{}
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	report, err := h.service.Bulk(r.Context(), user.ID, binderID, ops)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+func (h *handler) TransferCards(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	req := new(models.TransferRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.ToBinderID == "" {
+		http.Error(w, "to_binder_id and card_ids are required", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	report, err := h.service.TransferCards(r.Context(), user.ID, binderID, req.ToBinderID, req.CardIDs)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+func (h *handler) MoveCard(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	req := new(SlotRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.CardID == "" || req.To == nil {
+		http.Error(w, "card_id and to are required", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.MoveCard(r.Context(), user.ID, binderID, req.CardID, *req.To); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.renderPages(w, r, user.ID, binderID)
+}
+
+func (h *handler) SwapSlots(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	req := new(SlotRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.From == nil || req.To == nil {
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.SwapSlots(r.Context(), user.ID, binderID, *req.From, *req.To); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.renderPages(w, r, user.ID, binderID)
+}
+
+func (h *handler) InsertCard(w http.ResponseWriter, r *http.Request) {
+	binderID := chi.URLParam(r, "id")
+
+	req := new(SlotRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.CardID == "" || req.To == nil {
+		http.Error(w, "card_id and to are required", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.InsertCard(r.Context(), user.ID, binderID, req.CardID, *req.To); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.renderPages(w, r, user.ID, binderID)
+}
+
+func (h *handler) renderPages(w http.ResponseWriter, r *http.Request, userID, binderID string) {
+	pages, err := h.service.GetBinderPages(r.Context(), userID, binderID, cards.Expand{})
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, pages)
+}
+
+func (h *handler) ConvertToStatic(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	user := auth.UserFromContext(r.Context())
+
+	binder, err := h.service.ConvertToStatic(r.Context(), user.ID, id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binder)
+}
+
+func (h *handler) CreateShareToken(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	user := auth.UserFromContext(r.Context())
+
+	token, err := h.service.CreateShareToken(r.Context(), user.ID, id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, token)
+}
+
+func (h *handler) RevokeShareToken(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	token := chi.URLParam(r, "token")
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.RevokeShareToken(r.Context(), user.ID, id, token); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
@@ -188,39 +712,284 @@ func (h *handler) RemoveCard(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	binder, err := h.service.Delete(r.Context(), id)
+	user := auth.UserFromContext(r.Context())
+
+	binder, err := h.service.Delete(r.Context(), user.ID, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
 	render.JSON(w, r, binder)
 }
 
-func (h *handler) parseFilter(r *http.Request) map[string][]any {
-	filter := map[string][]any{}
+func (h *handler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	invite := MemberInvite{}
+	if err := json.NewDecoder(r.Body).Decode(&invite); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	values := r.URL.Query()
-	for key, value := range values {
-		if key == "page" || key == "limit" {
-			continue
+	user := auth.UserFromContext(r.Context())
+
+	member, err := h.service.AddMember(r.Context(), user.ID, id, invite)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, member)
+}
+
+func (h *handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	memberID := chi.URLParam(r, "user_id")
+	user := auth.UserFromContext(r.Context())
+
+	if err := h.service.RemoveMember(r.Context(), user.ID, id, memberID); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, nil)
+}
+
+func (h *handler) Export(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	format, err := ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	rows, err := h.service.Export(r.Context(), user.ID, id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	contentType := "application/json"
+	if format == FormatCSV {
+		contentType = "text/csv"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+"."+string(format)))
+
+	if err := EncodeRows(w, format, rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Import takes the file as the raw request body. The format comes from the
+// format query parameter, falling back to the content type.
+func (h *handler) Import(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	name := r.URL.Query().Get("format")
+	if name == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		name = string(FormatCSV)
+	}
+
+	format, err := ParseFormat(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := DecodeRows(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	report, err := h.service.Import(r.Context(), user.ID, id, rows)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+func (h *handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.NewPageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	user := auth.UserFromContext(r.Context())
+
+	events, err := h.service.GetHistory(r.Context(), user.ID, id, page)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, events)
+}
+
+func (h *handler) Undo(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	count := 1
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
+		count = n
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	binder, err := h.service.Undo(r.Context(), user.ID, id, count)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binder)
+}
+
+func (h *handler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+	if err != nil {
+		http.Error(w, "at must be an RFC 3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	binder, err := h.service.RestoreAt(r.Context(), user.ID, id, at)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, binder)
+}
+
+var binderTypes, binderVisibilities = func() ([]string, []string) {
+	types := make([]string, 0, len(models.BinderLayouts))
+	for t := range models.BinderLayouts {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+
+	visibilities := make([]string, 0, len(models.BinderVisibilities))
+	for v := range models.BinderVisibilities {
+		visibilities = append(visibilities, string(v))
+	}
+	sort.Strings(visibilities)
+
+	return types, visibilities
+}()
+
+var enumOps = []filters.Op{filters.OpEq, filters.OpNe, filters.OpNot, filters.OpIn, filters.OpNin}
+
+// binderFilters lists the binder fields a shelf listing may filter on.
+// folder_id only takes plain ids since the service expands it into
+// subfolders; an empty folder_id matches binders outside any folder.
+var binderFilters = filters.Schema{
+	"name":           {Kind: filters.String},
+	"type":           {Kind: filters.String, Enum: binderTypes, Ops: enumOps},
+	"visibility":     {Kind: filters.String, Enum: binderVisibilities, Ops: enumOps},
+	"is_favorite":    {Kind: filters.Bool},
+	"tags":           {Kind: filters.String, Many: true},
+	"folder_id":      {Kind: filters.String, Ops: []filters.Op{filters.OpEq}},
+	"shelf_position": {Kind: filters.Int},
+}
+
+func (h *handler) parseFilter(r *http.Request) (map[string][]any, error) {
+	return binderFilters.Parse(r.URL.Query(), "offset", "limit", "page", "sort", "order", "recursive")
+}
 
-		if key == "is_favorite" {
-			isFavorite, err := strconv.ParseBool(value[0])
-			if err != nil {
-				continue
-			}
-			filter[key] = []any{isFavorite}
-			continue
+// parseEntry reads the optional entry metadata from the body; the card id
+// always comes from the route.
+func (h *handler) parseEntry(r *http.Request) (models.BinderEntry, error) {
+	entry := models.BinderEntry{}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return entry, err
+	}
+
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &entry); err != nil {
+			return entry, err
 		}
+	}
+
+	entry.CardID = chi.URLParam(r, "card_id")
+	return entry, nil
+}
+
+// userID returns the logged in user, or an empty id for anonymous requests
+// on routes that only identify.
+func userID(r *http.Request) string {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		return ""
+	}
 
-		filter[key] = []any{}
+	return user.ID
+}
+
+var errorStatuses = map[error]int{
+	ErrNotFound:            http.StatusNotFound,
+	ErrCardNotInBinder:     http.StatusNotFound,
+	ErrCollectionNotFound:  http.StatusNotFound,
+	ErrShareTokenNotFound:  http.StatusNotFound,
+	ErrNoHistory:           http.StatusNotFound,
+	ErrMemberNotFound:      http.StatusNotFound,
+	ErrFolderNotFound:      http.StatusNotFound,
+	ErrPageNotFound:        http.StatusNotFound,
+	ErrTooManyPages:        http.StatusBadRequest,
+	ErrInvalidVariants:     http.StatusBadRequest,
+	ErrInvalidFolder:       http.StatusBadRequest,
+	ErrInvalidTag:          http.StatusBadRequest,
+	ErrInvalidSort:         http.StatusBadRequest,
+	ErrInvalidOrder:        http.StatusBadRequest,
+	ErrInvalidFormat:       http.StatusBadRequest,
+	ErrInvalidImport:       http.StatusBadRequest,
+	ErrInvalidBulk:         http.StatusBadRequest,
+	ErrTooManyOperations:   http.StatusRequestEntityTooLarge,
+	ErrTooManyRows:         http.StatusRequestEntityTooLarge,
+	ErrSameBinder:          http.StatusBadRequest,
+	ErrInvalidMember:       http.StatusBadRequest,
+	ErrInvalidEntry:        http.StatusBadRequest,
+	ErrInvalidType:         http.StatusBadRequest,
+	ErrInvalidPosition:     http.StatusBadRequest,
+	ErrInvalidVisibility:   http.StatusBadRequest,
+	ErrInvalidQuery:        http.StatusBadRequest,
+	ErrInvalidUndo:         http.StatusBadRequest,
+	ErrCardAlreadyInBinder: http.StatusConflict,
+	ErrSlotOccupied:        http.StatusConflict,
+	ErrConflict:            http.StatusConflict,
+	ErrSmartBinder:         http.StatusConflict,
+	ErrNotSmartBinder:      http.StatusConflict,
+	ErrForbidden:           http.StatusForbidden,
+}
 
-		for _, v := range value {
-			filter[key] = append(filter[key], v)
+func httpStatus(err error) int {
+	for target, status := range errorStatuses {
+		if errors.Is(err, target) {
+			return status
 		}
 	}
 
-	return filter
+	return http.StatusInternalServerError
 }
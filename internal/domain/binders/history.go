@@ -0,0 +1,304 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// record appends a history event for a change made by userID. before is the
+// state the change started from; the state after is read back from the
+// repository.
+func (s *service) record(ctx context.Context, userID, binderID string, action models.BinderEventAction, before *models.Binder) error {
+	return recordEvent(ctx, s.repo, s.historyRepo, userID, binderID, action, before)
+}
+
+func recordEvent(ctx context.Context, repo Repository, historyRepo HistoryRepository, userID, binderID string, action models.BinderEventAction, before *models.Binder) error {
+	var after *models.Binder
+
+	binder, err := repo.FindOne(ctx, binderID)
+	if err == nil {
+		after = &binder
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	// Version 7 ids are time ordered, so sorting by id sorts by sequence.
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	event := models.BinderEvent{
+		ID:       id.String(),
+		BinderID: binderID,
+		UserID:   userID,
+		Action:   action,
+		At:       time.Now().UTC(),
+		Before:   withoutShareTokens(before),
+		After:    withoutShareTokens(after),
+	}
+
+	return historyRepo.Upsert(ctx, event.ID, event)
+}
+
+// withoutShareTokens copies a snapshot without its share tokens. Restores keep
+// the current tokens, so history has no use for them and must not leak them
+// to editors.
+func withoutShareTokens(binder *models.Binder) *models.Binder {
+	if binder == nil {
+		return nil
+	}
+
+	out := *binder
+	out.ShareTokens = nil
+	return &out
+}
+
+func (s *service) latestEvents(ctx context.Context, binderID string, filter map[string][]any, n int) ([]models.BinderEvent, error) {
+	queryFilter := map[string][]any{}
+
+	for k, v := range filter {
+		queryFilter[k] = v
+	}
+
+	queryFilter["binder_id"] = []any{binderID}
+
+	return s.historyRepo.Search(ctx, queryFilter, wise.WithPage(0), wise.WithPageSize(n), wise.WithSort(map[string]int{"_id": -1}))
+}
+
+// authorizeHistory checks access to the history of a binder, which may
+// already be deleted. Deleted binders are checked against their last known
+// state. The current binder is nil when it no longer exists.
+func (s *service) authorizeHistory(ctx context.Context, userID, binderID string) (*models.Binder, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionHistory)
+	if err == nil {
+		return &binder, nil
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	events, err := s.latestEvents(ctx, binderID, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+
+	last := events[0].Before
+	if last == nil {
+		last = events[0].After
+	}
+
+	if last == nil {
+		return nil, ErrNotFound
+	}
+
+	if _, err := s.check(Actor{UserID: userID}, *last, ActionHistory); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (s *service) GetHistory(ctx context.Context, userID, binderID string, pagination pagination.Page) ([]models.BinderEvent, error) {
+	if _, err := s.authorizeHistory(ctx, userID, binderID); err != nil {
+		return nil, err
+	}
+
+	filter := map[string][]any{"binder_id": {binderID}}
+
+	events, err := s.historyRepo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	// Events recorded before snapshots were stripped may still hold tokens.
+	for i := range events {
+		events[i].Before = withoutShareTokens(events[i].Before)
+		events[i].After = withoutShareTokens(events[i].After)
+	}
+
+	return events, nil
+}
+
+// Undo reverts the last n recorded changes of a binder. Undoing a create
+// deletes the binder and undoing a delete brings it back.
+func (s *service) Undo(ctx context.Context, userID, binderID string, n int) (*models.Binder, error) {
+	if n < 1 {
+		return nil, ErrInvalidUndo
+	}
+
+	current, err := s.authorizeHistory(ctx, userID, binderID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.latestEvents(ctx, binderID, nil, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) < n {
+		return nil, ErrNoHistory
+	}
+
+	return s.applySnapshot(ctx, userID, binderID, current, events[n-1].Before, models.BinderEventUndo)
+}
+
+// RestoreAt brings a binder back to the state it had at the given time.
+func (s *service) RestoreAt(ctx context.Context, userID, binderID string, at time.Time) (*models.Binder, error) {
+	current, err := s.authorizeHistory(ctx, userID, binderID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := map[string][]any{"at": {bson.M{"$lte": at}}}
+
+	events, err := s.latestEvents(ctx, binderID, filter, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 || events[0].After == nil {
+		return nil, ErrNoHistory
+	}
+
+	return s.applySnapshot(ctx, userID, binderID, current, events[0].After, models.BinderEventRestore)
+}
+
+// applySnapshot makes state the current binder. Going back past a create or
+// a delete removes or recreates the binder, which needs the right to delete
+// it; the owner and, without the matching rights, the visibility and shelf
+// organisation stay as they are now.
+func (s *service) applySnapshot(ctx context.Context, userID, binderID string, current, state *models.Binder, action models.BinderEventAction) (*models.Binder, error) {
+	actor := Actor{UserID: userID}
+
+	if state == nil {
+		if current == nil {
+			return nil, nil
+		}
+
+		if err := s.policy.Authorize(actor, *current, ActionDelete); err != nil {
+			return nil, err
+		}
+
+		if _, err := s.repo.Delete(ctx, binderID); err != nil {
+			return nil, err
+		}
+
+		return nil, s.record(ctx, userID, binderID, action, current)
+	}
+
+	if current == nil {
+		if err := s.policy.Authorize(actor, *state, ActionDelete); err != nil {
+			return nil, err
+		}
+	}
+
+	restored := *state
+	restored.ShareTokens = nil
+	restored.Version = state.Version + 1
+
+	// Share tokens and members are access control, not content: revoked
+	// access must stay revoked after a restore. current may have been
+	// redacted for the actor, so read them back unfiltered.
+	if current != nil {
+		raw, err := s.repo.FindOne(ctx, binderID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		restored.ShareTokens = raw.ShareTokens
+		restored.Members = raw.Members
+		restored.Version = raw.Version + 1
+		restored.UserID = raw.UserID
+
+		if s.policy.Authorize(actor, raw, ActionShare) != nil {
+			restored.Visibility = raw.Visibility
+		}
+
+		if s.policy.Authorize(actor, raw, ActionOrganize) != nil {
+			restored.Tags = raw.Tags
+			restored.FolderID = raw.FolderID
+			restored.ShelfPosition = raw.ShelfPosition
+		}
+	}
+
+	if err := s.dropDeletedCards(ctx, &restored); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Restore(ctx, restored); err != nil {
+		return nil, err
+	}
+
+	if err := s.record(ctx, userID, binderID, action, current); err != nil {
+		return nil, err
+	}
+
+	restored.Role = restored.RoleOf(userID)
+	if restored.Role != models.BinderRoleOwner {
+		restored.ShareTokens = nil
+	}
+
+	return &restored, nil
+}
+
+// dropDeletedCards removes the entries and placeholders of a snapshot whose
+// cards were deleted from the catalog since it was taken.
+func (s *service) dropDeletedCards(ctx context.Context, binder *models.Binder) error {
+	cardIDs := make([]string, 0, len(binder.Entries)+len(binder.Placeholders))
+	for _, entry := range binder.Entries {
+		cardIDs = append(cardIDs, entry.CardID)
+	}
+	for _, placeholder := range binder.Placeholders {
+		cardIDs = append(cardIDs, placeholder.CardID)
+	}
+
+	if len(cardIDs) == 0 {
+		return nil
+	}
+
+	cards, err := s.cardRepo.Find(ctx, cardIDs)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]struct{}, len(cards))
+	for _, card := range cards {
+		exists[card.ID] = struct{}{}
+	}
+
+	entries := make([]models.BinderEntry, 0, len(binder.Entries))
+	for _, entry := range binder.Entries {
+		if _, ok := exists[entry.CardID]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	binder.Entries = entries
+
+	placeholders := make([]models.BinderPlaceholder, 0, len(binder.Placeholders))
+	for _, placeholder := range binder.Placeholders {
+		if _, ok := exists[placeholder.CardID]; ok {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+	binder.Placeholders = placeholders
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package binders
+
+import (
+	"sort"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+// maxPockets bounds how far into a binder cards can be placed. Relayouts keep
+// pocket indexes, so the bound holds whatever the binder type.
+const maxPockets = 10000
+
+func slotIndex(layout models.PageLayout, pos models.SlotPosition) int {
+	return pos.Page*layout.Slots() + pos.Slot
+}
+
+func slotPosition(layout models.PageLayout, index int) models.SlotPosition {
+	return models.SlotPosition{
+		Page: index / layout.Slots(),
+		Slot: index % layout.Slots(),
+	}
+}
+
+func validatePosition(layout models.PageLayout, pos models.SlotPosition) error {
+	if pos.Page < 0 || pos.Slot < 0 || pos.Slot >= layout.Slots() {
+		return ErrInvalidPosition
+	}
+
+	if pos.Page >= maxPockets || slotIndex(layout, pos) >= maxPockets {
+		return ErrInvalidPosition
+	}
+
+	return nil
+}
+
+// validateLayout checks that every placed entry fits the layout and that no
+// two entries share a pocket.
+func validateLayout(layout models.PageLayout, entries []models.BinderEntry) error {
+	taken := make(map[int]struct{}, len(entries))
+
+	for _, e := range entries {
+		if e.Position == nil {
+			continue
+		}
+
+		if err := validatePosition(layout, *e.Position); err != nil {
+			return err
+		}
+
+		idx := slotIndex(layout, *e.Position)
+		if _, ok := taken[idx]; ok {
+			return ErrSlotOccupied
+		}
+		taken[idx] = struct{}{}
+	}
+
+	return nil
+}
+
+// occupancy maps pocket indexes to positions in entries.
+func occupancy(layout models.PageLayout, entries []models.BinderEntry) map[int]int {
+	slots := make(map[int]int, len(entries))
+
+	for i, e := range entries {
+		if e.Position != nil {
+			slots[slotIndex(layout, *e.Position)] = i
+		}
+	}
+
+	return slots
+}
+
+func findEntry(entries []models.BinderEntry, cardID string) int {
+	for i, e := range entries {
+		if e.CardID == cardID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func copyEntries(entries []models.BinderEntry) []models.BinderEntry {
+	out := make([]models.BinderEntry, len(entries))
+	copy(out, entries)
+
+	for i := range out {
+		if out[i].Position != nil {
+			pos := *out[i].Position
+			out[i].Position = &pos
+		}
+	}
+
+	return out
+}
+
+// moveCard places cardID into an empty pocket.
+func moveCard(layout models.PageLayout, entries []models.BinderEntry, cardID string, to models.SlotPosition) ([]models.BinderEntry, error) {
+	if err := validatePosition(layout, to); err != nil {
+		return nil, err
+	}
+
+	entries = copyEntries(entries)
+
+	i := findEntry(entries, cardID)
+	if i < 0 {
+		return nil, ErrCardNotInBinder
+	}
+
+	if j, ok := occupancy(layout, entries)[slotIndex(layout, to)]; ok && j != i {
+		return nil, ErrSlotOccupied
+	}
+
+	entries[i].Position = &to
+	return entries, nil
+}
+
+// swapSlots exchanges the contents of two pockets; either may be empty.
+func swapSlots(layout models.PageLayout, entries []models.BinderEntry, a, b models.SlotPosition) ([]models.BinderEntry, error) {
+	if err := validatePosition(layout, a); err != nil {
+		return nil, err
+	}
+
+	if err := validatePosition(layout, b); err != nil {
+		return nil, err
+	}
+
+	entries = copyEntries(entries)
+	slots := occupancy(layout, entries)
+
+	i, okA := slots[slotIndex(layout, a)]
+	j, okB := slots[slotIndex(layout, b)]
+
+	if okA {
+		entries[i].Position = &b
+	}
+
+	if okB {
+		entries[j].Position = &a
+	}
+
+	return entries, nil
+}
+
+// insertCard places cardID at the given pocket, pushing the cards from that
+// pocket onwards forward by one until the first empty pocket.
+func insertCard(layout models.PageLayout, entries []models.BinderEntry, cardID string, at models.SlotPosition) ([]models.BinderEntry, error) {
+	if err := validatePosition(layout, at); err != nil {
+		return nil, err
+	}
+
+	entries = copyEntries(entries)
+
+	i := findEntry(entries, cardID)
+	if i < 0 {
+		return nil, ErrCardNotInBinder
+	}
+
+	entries[i].Position = nil
+	slots := occupancy(layout, entries)
+
+	start := slotIndex(layout, at)
+	end := start
+	for {
+		if _, ok := slots[end]; !ok {
+			break
+		}
+		end++
+	}
+
+	if end >= maxPockets {
+		return nil, ErrInvalidPosition
+	}
+
+	for idx := end - 1; idx >= start; idx-- {
+		pos := slotPosition(layout, idx+1)
+		entries[slots[idx]].Position = &pos
+	}
+
+	entries[i].Position = &at
+	return entries, nil
+}
+
+// relayout keeps every placed card at the same pocket index when the page
+// layout changes, so order and gaps survive switching binder types.
+func relayout(from, to models.PageLayout, entries []models.BinderEntry) []models.BinderEntry {
+	entries = copyEntries(entries)
+
+	for i, e := range entries {
+		if e.Position == nil {
+			continue
+		}
+
+		pos := slotPosition(to, slotIndex(from, *e.Position))
+		entries[i].Position = &pos
+	}
+
+	return entries
+}
+
+// arrangePages lays cards out in their pockets. Missing placeholder cards
+// fill the pockets reserved for them as long as nothing else sits there.
+// Only pages holding a card are returned, in page order.
+func arrangePages(binder models.Binder, cards, missing []models.BinderCard) models.BinderPages {
+	layout := binder.Layout()
+
+	result := models.BinderPages{
+		Type:   binder.Type,
+		Layout: layout,
+		Pages:  []models.BinderPage{},
+		Loose:  []models.BinderCard{},
+	}
+
+	pages := map[int]*models.BinderPage{}
+	page := func(number int) []*models.BinderCard {
+		if p, ok := pages[number]; ok {
+			return p.Slots
+		}
+
+		p := &models.BinderPage{Number: number, Slots: make([]*models.BinderCard, layout.Slots())}
+		pages[number] = p
+		return p.Slots
+	}
+
+	for i := range cards {
+		card := cards[i]
+
+		if card.Position == nil {
+			result.Loose = append(result.Loose, card)
+			continue
+		}
+
+		page(card.Position.Page)[card.Position.Slot] = &card
+	}
+
+	for i := range missing {
+		card := missing[i]
+
+		if slots := page(card.Position.Page); slots[card.Position.Slot] == nil {
+			slots[card.Position.Slot] = &card
+		}
+	}
+
+	for _, p := range pages {
+		result.Pages = append(result.Pages, *p)
+	}
+	sort.Slice(result.Pages, func(i, j int) bool {
+		return result.Pages[i].Number < result.Pages[j].Number
+	})
+
+	return result
+}
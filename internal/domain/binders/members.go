@@ -0,0 +1,95 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemberInvite names the user to share a binder with, by id or by email.
+type MemberInvite struct {
+	UserID string            `json:"user_id"`
+	Email  string            `json:"email"`
+	Role   models.BinderRole `json:"role"`
+}
+
+func (s *service) AddMember(ctx context.Context, userID, binderID string, invite MemberInvite) (models.BinderMember, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionManageMembers)
+	if err != nil {
+		return models.BinderMember{}, err
+	}
+
+	if invite.Role != models.BinderRoleEditor && invite.Role != models.BinderRoleViewer {
+		return models.BinderMember{}, ErrInvalidMember
+	}
+
+	memberID, err := s.resolveUser(ctx, invite)
+	if err != nil {
+		return models.BinderMember{}, err
+	}
+
+	if memberID == binder.UserID {
+		return models.BinderMember{}, ErrInvalidMember
+	}
+
+	member := models.BinderMember{
+		UserID:  memberID,
+		Role:    invite.Role,
+		AddedAt: time.Now().UTC(),
+	}
+
+	if err := s.repo.SetMember(ctx, binderID, member); err != nil {
+		return models.BinderMember{}, err
+	}
+
+	return member, nil
+}
+
+// RemoveMember revokes a membership. Members may always remove themselves.
+func (s *service) RemoveMember(ctx context.Context, userID, binderID, memberID string) error {
+	action := ActionManageMembers
+	if userID == memberID {
+		action = ActionRead
+	}
+
+	if _, err := s.authorize(ctx, Actor{UserID: userID}, binderID, action); err != nil {
+		return err
+	}
+
+	return s.repo.RemoveMember(ctx, binderID, memberID)
+}
+
+func (s *service) resolveUser(ctx context.Context, invite MemberInvite) (string, error) {
+	if invite.UserID != "" {
+		user, err := s.userRepo.FindOne(ctx, invite.UserID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrInvalidMember
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		return user.ID, nil
+	}
+
+	email := strings.ToLower(strings.TrimSpace(invite.Email))
+	if email == "" {
+		return "", ErrInvalidMember
+	}
+
+	found, err := s.userRepo.Search(ctx, map[string][]any{"email": {email}})
+	if err != nil {
+		return "", err
+	}
+
+	if len(found) == 0 {
+		return "", ErrInvalidMember
+	}
+
+	return found[0].ID, nil
+}
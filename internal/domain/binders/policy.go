@@ -0,0 +1,74 @@
+package binders
+
+import "github.com/seosoojin/dalkom/pkg/models"
+
+type Action string
+
+const (
+	ActionRead          Action = "read"
+	ActionUpdate        Action = "update"
+	ActionDelete        Action = "delete"
+	ActionShare         Action = "share"
+	ActionHistory       Action = "history"
+	ActionManageMembers Action = "manage_members"
+	ActionOrganize      Action = "organize"
+)
+
+// Actor is whoever is asking: a logged in user, an anonymous visitor holding
+// a share token, or neither.
+type Actor struct {
+	UserID     string
+	ShareToken string
+}
+
+// Policy decides whether an actor may perform an action on a binder. Every
+// binder operation in Service goes through it.
+type Policy interface {
+	Authorize(actor Actor, binder models.Binder, action Action) error
+}
+
+var roleActions = map[models.BinderRole]map[Action]struct{}{
+	models.BinderRoleEditor: {
+		ActionRead:    {},
+		ActionUpdate:  {},
+		ActionHistory: {},
+	},
+	models.BinderRoleViewer: {
+		ActionRead: {},
+	},
+}
+
+type accessPolicy struct {
+}
+
+var _ Policy = &accessPolicy{}
+
+func NewAccessPolicy() *accessPolicy {
+	return &accessPolicy{}
+}
+
+func (p *accessPolicy) Authorize(actor Actor, binder models.Binder, action Action) error {
+	role := binder.RoleOf(actor.UserID)
+	if role == models.BinderRoleOwner {
+		return nil
+	}
+
+	if _, ok := roleActions[role][action]; ok {
+		return nil
+	}
+
+	if action != ActionRead {
+		return ErrForbidden
+	}
+
+	switch binder.Visibility {
+	case models.BinderVisibilityPublic:
+		return nil
+	case models.BinderVisibilityUnlisted:
+		if actor.ShareToken != "" && binder.HasShareToken(actor.ShareToken) {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
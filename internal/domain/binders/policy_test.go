@@ -0,0 +1,101 @@
+package binders
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+func TestAccessPolicyAuthorize(t *testing.T) {
+	binder := func(visibility models.BinderVisibility) models.Binder {
+		return models.Binder{
+			ID:         "binder",
+			UserID:     "owner",
+			Visibility: visibility,
+			Members: []models.BinderMember{
+				{UserID: "editor", Role: models.BinderRoleEditor},
+				{UserID: "viewer", Role: models.BinderRoleViewer},
+			},
+			ShareTokens: []models.ShareToken{{Token: "valid"}},
+		}
+	}
+
+	private := binder(models.BinderVisibilityPrivate)
+	public := binder(models.BinderVisibilityPublic)
+	unlisted := binder(models.BinderVisibilityUnlisted)
+
+	tests := []struct {
+		name   string
+		actor  Actor
+		binder models.Binder
+		action Action
+		want   error
+	}{
+		{"owner reads private", Actor{UserID: "owner"}, private, ActionRead, nil},
+		{"owner updates", Actor{UserID: "owner"}, private, ActionUpdate, nil},
+		{"owner deletes", Actor{UserID: "owner"}, private, ActionDelete, nil},
+		{"owner shares", Actor{UserID: "owner"}, private, ActionShare, nil},
+		{"owner manages members", Actor{UserID: "owner"}, private, ActionManageMembers, nil},
+		{"owner organizes", Actor{UserID: "owner"}, private, ActionOrganize, nil},
+
+		{"non-owner reads private", Actor{UserID: "stranger"}, private, ActionRead, ErrForbidden},
+		{"non-owner updates public", Actor{UserID: "stranger"}, public, ActionUpdate, ErrForbidden},
+		{"non-owner deletes public", Actor{UserID: "stranger"}, public, ActionDelete, ErrForbidden},
+		{"non-owner reads public", Actor{UserID: "stranger"}, public, ActionRead, nil},
+
+		{"anonymous reads private", Actor{}, private, ActionRead, ErrForbidden},
+		{"anonymous reads public", Actor{}, public, ActionRead, nil},
+		{"anonymous updates public", Actor{}, public, ActionUpdate, ErrForbidden},
+
+		{"editor reads private", Actor{UserID: "editor"}, private, ActionRead, nil},
+		{"editor updates", Actor{UserID: "editor"}, private, ActionUpdate, nil},
+		{"editor reads history", Actor{UserID: "editor"}, private, ActionHistory, nil},
+		{"editor deletes", Actor{UserID: "editor"}, private, ActionDelete, ErrForbidden},
+		{"editor shares", Actor{UserID: "editor"}, private, ActionShare, ErrForbidden},
+		{"editor manages members", Actor{UserID: "editor"}, private, ActionManageMembers, ErrForbidden},
+		{"editor organizes", Actor{UserID: "editor"}, private, ActionOrganize, ErrForbidden},
+
+		{"viewer reads private", Actor{UserID: "viewer"}, private, ActionRead, nil},
+		{"viewer updates", Actor{UserID: "viewer"}, private, ActionUpdate, ErrForbidden},
+		{"viewer reads history", Actor{UserID: "viewer"}, private, ActionHistory, ErrForbidden},
+		{"viewer deletes", Actor{UserID: "viewer"}, private, ActionDelete, ErrForbidden},
+
+		{"unlisted with valid token", Actor{ShareToken: "valid"}, unlisted, ActionRead, nil},
+		{"unlisted with invalid token", Actor{ShareToken: "invalid"}, unlisted, ActionRead, ErrForbidden},
+		{"unlisted without token", Actor{}, unlisted, ActionRead, ErrForbidden},
+		{"unlisted non-owner without token", Actor{UserID: "stranger"}, unlisted, ActionRead, ErrForbidden},
+		{"unlisted token cannot update", Actor{ShareToken: "valid"}, unlisted, ActionUpdate, ErrForbidden},
+		{"private with valid token", Actor{ShareToken: "valid"}, private, ActionRead, ErrForbidden},
+	}
+
+	policy := NewAccessPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(tt.actor, tt.binder, tt.action)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Authorize() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrForbidden, http.StatusForbidden},
+		{ErrNotFound, http.StatusNotFound},
+		{fmt.Errorf("loading binder: %w", ErrNotFound), http.StatusNotFound},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := httpStatus(tt.err); got != tt.want {
+			t.Errorf("httpStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package binders
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/gateways/images"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+const (
+	cardWidth   = 180
+	cardHeight  = 278
+	slotGap     = 12
+	pageMargin  = 24
+	pageGap     = 48
+	badgeRadius = 14
+
+	// maxRenderedPages bounds whole binder renders; larger binders are
+	// rendered page by page.
+	maxRenderedPages = 20
+	// maxImageLoads bounds concurrent card image downloads per render.
+	maxImageLoads = 8
+	// maxCachedBinders bounds how many binders keep rendered images.
+	maxCachedBinders = 256
+
+	wholeBinder = -1
+)
+
+var (
+	backgroundColor = color.RGBA{250, 248, 245, 255}
+	pocketColor     = color.RGBA{228, 225, 220, 255}
+	ownedColor      = color.RGBA{46, 160, 90, 255}
+	missingColor    = color.RGBA{150, 150, 150, 255}
+	washColor       = color.NRGBA{255, 255, 255, 150}
+)
+
+// Renderer draws binder pages as PNG images for sharing. Owned cards get a
+// green badge; placeholder pockets of missing cards are washed out.
+type Renderer interface {
+	RenderPage(ctx context.Context, userID, binderID string, page int) ([]byte, error)
+	RenderBinder(ctx context.Context, userID, binderID string) ([]byte, error)
+}
+
+type renderer struct {
+	service Service
+	source  images.Source
+	cache   *renderCache
+}
+
+var _ Renderer = &renderer{}
+
+func NewRenderer(service Service, source images.Source) *renderer {
+	return &renderer{
+		service: service,
+		source:  source,
+		cache:   newRenderCache(maxCachedBinders),
+	}
+}
+
+func (r *renderer) RenderPage(ctx context.Context, userID, binderID string, page int) ([]byte, error) {
+	if page < 0 {
+		return nil, ErrPageNotFound
+	}
+
+	return r.render(ctx, userID, binderID, page)
+}
+
+func (r *renderer) RenderBinder(ctx context.Context, userID, binderID string) ([]byte, error) {
+	return r.render(ctx, userID, binderID, wholeBinder)
+}
+
+// render serves from the cache while the binder version is unchanged. The
+// version is read before the pages, so a concurrent change can only leave a
+// newer image under an older version, which the next read replaces. Smart
+// binders follow the catalog and are never cached.
+func (r *renderer) render(ctx context.Context, userID, binderID string, page int) ([]byte, error) {
+	binder, err := r.service.GetByID(ctx, userID, binderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the size from the stored positions before building any page.
+	switch count := pageCount(binder); {
+	case page == wholeBinder && count > maxRenderedPages:
+		return nil, ErrTooManyPages
+	case page != wholeBinder && page >= count:
+		return nil, ErrPageNotFound
+	}
+
+	cacheable := !binder.IsSmart()
+	if cacheable {
+		if out, ok := r.cache.get(binderID, binder.Version, page); ok {
+			return out, nil
+		}
+	}
+
+	pages, err := r.service.GetBinderPages(ctx, userID, binderID, cards.Expand{})
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectPages(pages, page)
+	if err != nil {
+		return nil, err
+	}
+
+	img := r.draw(ctx, pages.Layout, selected)
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	out := buf.Bytes()
+	if cacheable {
+		r.cache.put(binderID, binder.Version, page, out)
+	}
+
+	return out, nil
+}
+
+// pageCount is the number of pages up to the last one holding a card or the
+// placeholder of a missing card.
+func pageCount(binder models.Binder) int {
+	count := 0
+	held := make(map[string]struct{}, len(binder.Entries))
+	for _, e := range binder.Entries {
+		held[e.CardID] = struct{}{}
+		if e.Position != nil {
+			count = max(count, e.Position.Page+1)
+		}
+	}
+
+	for _, p := range binder.Placeholders {
+		if _, ok := held[p.CardID]; !ok {
+			count = max(count, p.Position.Page+1)
+		}
+	}
+
+	return count
+}
+
+// selectPages picks the pages to draw. Pages only lists pages holding a card,
+// so the empty pages in between are filled in blank.
+func selectPages(pages models.BinderPages, page int) ([]models.BinderPage, error) {
+	count := 0
+	if n := len(pages.Pages); n > 0 {
+		count = pages.Pages[n-1].Number + 1
+	}
+
+	switch {
+	case page == wholeBinder && count > maxRenderedPages:
+		return nil, ErrTooManyPages
+	case page != wholeBinder && page >= count:
+		return nil, ErrPageNotFound
+	}
+
+	first, last := page, page
+	if page == wholeBinder {
+		first, last = 0, max(count, 1)-1
+	}
+
+	selected := make([]models.BinderPage, 0, last-first+1)
+	for number := first; number <= last; number++ {
+		selected = append(selected, models.BinderPage{Number: number, Slots: make([]*models.BinderCard, pages.Layout.Slots())})
+	}
+	for _, p := range pages.Pages {
+		if p.Number >= first && p.Number <= last {
+			selected[p.Number-first] = p
+		}
+	}
+
+	return selected, nil
+}
+
+func (r *renderer) draw(ctx context.Context, layout models.PageLayout, pages []models.BinderPage) image.Image {
+	pageWidth := layout.Columns*cardWidth + (layout.Columns-1)*slotGap
+	pageHeight := layout.Rows*cardHeight + (layout.Rows-1)*slotGap
+
+	width := pageWidth + 2*pageMargin
+	height := len(pages)*pageHeight + (len(pages)-1)*pageGap + 2*pageMargin
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	loaded := r.loadImages(ctx, pages)
+
+	for p, page := range pages {
+		top := pageMargin + p*(pageHeight+pageGap)
+
+		for i, card := range page.Slots {
+			x := pageMargin + (i%layout.Columns)*(cardWidth+slotGap)
+			y := top + (i/layout.Columns)*(cardHeight+slotGap)
+			rect := image.Rect(x, y, x+cardWidth, y+cardHeight)
+
+			if card == nil {
+				draw.Draw(dst, rect, image.NewUniform(pocketColor), image.Point{}, draw.Src)
+				continue
+			}
+
+			if src := loaded[card.Card.ImageUrl]; src != nil {
+				drawCover(dst, rect, src)
+			} else {
+				draw.Draw(dst, rect, image.NewUniform(fallbackColor(card.CardID)), image.Point{}, draw.Src)
+			}
+
+			badge := image.Pt(rect.Max.X-badgeRadius-6, rect.Min.Y+badgeRadius+6)
+			if card.Owned {
+				drawDisc(dst, badge, badgeRadius, ownedColor)
+				continue
+			}
+
+			grayscale(dst, rect)
+			draw.Draw(dst, rect, image.NewUniform(washColor), image.Point{}, draw.Over)
+			drawDisc(dst, badge, badgeRadius, missingColor)
+			drawDisc(dst, badge, badgeRadius-4, backgroundColor)
+		}
+	}
+
+	return dst
+}
+
+// loadImages fetches every distinct card image of the pages concurrently.
+// Images that fail to load are left out and drawn as a plain color.
+func (r *renderer) loadImages(ctx context.Context, pages []models.BinderPage) map[string]image.Image {
+	refs := map[string]struct{}{}
+	for _, page := range pages {
+		for _, card := range page.Slots {
+			if card != nil && card.Card.ImageUrl != "" {
+				refs[card.Card.ImageUrl] = struct{}{}
+			}
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		loaded = make(map[string]image.Image, len(refs))
+		limit  = make(chan struct{}, maxImageLoads)
+	)
+
+	for ref := range refs {
+		wg.Add(1)
+		go func(ref string) {
+			defer wg.Done()
+
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			img, err := r.source.Load(ctx, ref)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			loaded[ref] = img
+			mu.Unlock()
+		}(ref)
+	}
+
+	wg.Wait()
+	return loaded
+}
+
+// drawCover scales src to cover rect, cropping the overflow evenly, with
+// nearest neighbour sampling.
+func drawCover(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	b := src.Bounds()
+	if b.Empty() {
+		return
+	}
+
+	scale := max(float64(rect.Dx())/float64(b.Dx()), float64(rect.Dy())/float64(b.Dy()))
+	offsetX := (float64(b.Dx())*scale - float64(rect.Dx())) / 2
+	offsetY := (float64(b.Dy())*scale - float64(rect.Dy())) / 2
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		sy := b.Min.Y + int((float64(y-rect.Min.Y)+offsetY)/scale)
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			sx := b.Min.X + int((float64(x-rect.Min.X)+offsetX)/scale)
+			dst.Set(x, y, src.At(min(sx, b.Max.X-1), min(sy, b.Max.Y-1)))
+		}
+	}
+}
+
+func grayscale(dst *image.RGBA, rect image.Rectangle) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			g := color.GrayModel.Convert(dst.At(x, y)).(color.Gray)
+			dst.SetRGBA(x, y, color.RGBA{g.Y, g.Y, g.Y, 255})
+		}
+	}
+}
+
+func drawDisc(dst *image.RGBA, center image.Point, radius int, c color.RGBA) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				dst.SetRGBA(center.X+x, center.Y+y, c)
+			}
+		}
+	}
+}
+
+// fallbackColor gives cards without a loadable image a stable pastel color.
+func fallbackColor(cardID string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(cardID))
+	sum := h.Sum32()
+
+	return color.RGBA{
+		R: 160 + uint8(sum%96),
+		G: 160 + uint8((sum>>8)%96),
+		B: 160 + uint8((sum>>16)%96),
+		A: 255,
+	}
+}
+
+// renderCache keeps the rendered images of a binder for a single version, so
+// any change to the binder invalidates them.
+type renderCache struct {
+	mu      sync.Mutex
+	max     int
+	order   []string
+	entries map[string]*cachedRender
+}
+
+type cachedRender struct {
+	version int
+	pages   map[int][]byte
+}
+
+func newRenderCache(max int) *renderCache {
+	return &renderCache{
+		max:     max,
+		entries: map[string]*cachedRender{},
+	}
+}
+
+func (c *renderCache) get(binderID string, version, page int) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[binderID]
+	if !ok || entry.version != version {
+		return nil, false
+	}
+
+	out, ok := entry.pages[page]
+	return out, ok
+}
+
+func (c *renderCache) put(binderID string, version, page int, out []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[binderID]
+	if !ok {
+		for len(c.order) >= c.max {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, binderID)
+	}
+
+	if !ok || entry.version != version {
+		entry = &cachedRender{version: version, pages: map[int][]byte{}}
+		c.entries[binderID] = entry
+	}
+
+	entry.pages[page] = out
+}
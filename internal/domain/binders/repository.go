@@ -1,8 +1,495 @@
 package binders
 
 import (
+	"context"
+
 	"github.com/nextlevellabs/go-wise/wise"
 	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Repository wise.MongoRepository[models.Binder]
+type HistoryRepository wise.MongoRepository[models.BinderEvent]
+
+type FolderRepository wise.MongoRepository[models.BinderFolder]
+
+type Repository interface {
+	wise.MongoRepository[models.Binder]
+
+	AddEntry(ctx context.Context, binderID string, entry models.BinderEntry) error
+	UpdateEntry(ctx context.Context, binderID string, entry models.BinderEntry) error
+	RemoveEntry(ctx context.Context, binderID, cardID string) error
+	ReplaceEntries(ctx context.Context, binderID string, version int, entries []models.BinderEntry) error
+	ConvertToStatic(ctx context.Context, binderID string, version int, entries []models.BinderEntry) error
+
+	AddShareToken(ctx context.Context, binderID string, token models.ShareToken) error
+	RemoveShareToken(ctx context.Context, binderID, token string) error
+
+	Restore(ctx context.Context, binder models.Binder) error
+
+	SetMember(ctx context.Context, binderID string, member models.BinderMember) error
+	RemoveMember(ctx context.Context, binderID, userID string) error
+
+	SetTags(ctx context.Context, binderID string, tags []string) error
+	SetFolder(ctx context.Context, binderID, folderID string) error
+	MoveFolderContents(ctx context.Context, userID, from, to string) error
+	SetShelfOrder(ctx context.Context, userID string, binderIDs []string) error
+
+	MigrateLegacyCardIDs(ctx context.Context) (int64, error)
+	HoldingCards(ctx context.Context, cardIDs []string) ([]string, error)
+	DetachCards(ctx context.Context, cardIDs []string) (int64, error)
+	DetachCardsFrom(ctx context.Context, binderID string, cardIDs []string) error
+
+	Stats(ctx context.Context, filter bson.M) (models.BinderStats, error)
+}
+
+// Catalog holds the catalog collections the stats aggregation joins.
+type Catalog struct {
+	Cards       *mongo.Collection
+	Groups      *mongo.Collection
+	Idols       *mongo.Collection
+	Collections *mongo.Collection
+}
+
+type repository struct {
+	wise.MongoRepository[models.Binder]
+	collection *mongo.Collection
+	catalog    Catalog
+}
+
+var _ Repository = &repository{}
+
+func NewRepository(col *mongo.Collection, catalog Catalog) (*repository, error) {
+	repo, err := wise.NewMongoSimpleRepository[models.Binder](col)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		MongoRepository: repo,
+		collection:      col,
+		catalog:         catalog,
+	}, nil
+}
+
+func (r *repository) FindOne(ctx context.Context, id string) (models.Binder, error) {
+	binder, err := r.MongoRepository.FindOne(ctx, id)
+	if err != nil {
+		return models.Binder{}, err
+	}
+
+	binder.MigrateLegacyCardIDs()
+	return binder, nil
+}
+
+// AddEntry atomically appends entry to the binder, failing with
+// ErrCardAlreadyInBinder when the card is already there.
+func (r *repository) AddEntry(ctx context.Context, binderID string, entry models.BinderEntry) error {
+	filter := bson.M{"_id": binderID, "entries.card_id": bson.M{"$ne": entry.CardID}}
+	update := bson.M{"$push": bson.M{"entries": entry}, "$inc": bson.M{"version": 1}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrCardAlreadyInBinder)
+	}
+
+	return nil
+}
+
+// UpdateEntry atomically replaces the entry holding entry.CardID, failing
+// with ErrCardNotInBinder when the card is not there.
+func (r *repository) UpdateEntry(ctx context.Context, binderID string, entry models.BinderEntry) error {
+	filter := bson.M{"_id": binderID, "entries.card_id": entry.CardID}
+	update := bson.M{"$set": bson.M{"entries.$": entry}, "$inc": bson.M{"version": 1}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrCardNotInBinder)
+	}
+
+	return nil
+}
+
+// RemoveEntry atomically pulls the entry holding cardID, failing with
+// ErrCardNotInBinder when the card is not there.
+func (r *repository) RemoveEntry(ctx context.Context, binderID, cardID string) error {
+	filter := bson.M{"_id": binderID, "entries.card_id": cardID}
+	update := bson.M{"$pull": bson.M{"entries": bson.M{"card_id": cardID}}, "$inc": bson.M{"version": 1}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrCardNotInBinder)
+	}
+
+	return nil
+}
+
+// ReplaceEntries overwrites all entries as long as the binder is still at
+// version, failing with ErrConflict when someone else changed it first.
+func (r *repository) ReplaceEntries(ctx context.Context, binderID string, version int, entries []models.BinderEntry) error {
+	return r.replaceEntries(ctx, binderID, version, entries, bson.M{"card_ids": ""})
+}
+
+// ConvertToStatic is ReplaceEntries that also drops the smart binder query.
+func (r *repository) ConvertToStatic(ctx context.Context, binderID string, version int, entries []models.BinderEntry) error {
+	return r.replaceEntries(ctx, binderID, version, entries, bson.M{"card_ids": "", "query": ""})
+}
+
+func (r *repository) replaceEntries(ctx context.Context, binderID string, version int, entries []models.BinderEntry, unset bson.M) error {
+	filter := bson.M{"_id": binderID, "version": version}
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"entries": entries},
+		"$unset": unset,
+		"$inc":   bson.M{"version": 1},
+	}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrConflict)
+	}
+
+	return nil
+}
+
+// Restore replaces the whole binder document, recreating it if it was
+// deleted.
+func (r *repository) Restore(ctx context.Context, binder models.Binder) error {
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": binder.ID}, binder, opts)
+	return err
+}
+
+func (r *repository) AddShareToken(ctx context.Context, binderID string, token models.ShareToken) error {
+	update := bson.M{"$push": bson.M{"share_tokens": token}}
+
+	res, err := r.collection.UpdateByID(ctx, binderID, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) RemoveShareToken(ctx context.Context, binderID, token string) error {
+	filter := bson.M{"_id": binderID, "share_tokens.token": token}
+	update := bson.M{"$pull": bson.M{"share_tokens": bson.M{"token": token}}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrShareTokenNotFound)
+	}
+
+	return nil
+}
+
+// SetMember adds the member or changes the role of an existing one.
+func (r *repository) SetMember(ctx context.Context, binderID string, member models.BinderMember) error {
+	filter := bson.M{"_id": binderID, "members.user_id": member.UserID}
+	update := bson.M{"$set": bson.M{"members.$.role": member.Role}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	filter = bson.M{"_id": binderID, "members.user_id": bson.M{"$ne": member.UserID}}
+	update = bson.M{"$push": bson.M{"members": member}}
+
+	res, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrConflict)
+	}
+
+	return nil
+}
+
+func (r *repository) RemoveMember(ctx context.Context, binderID, userID string) error {
+	filter := bson.M{"_id": binderID, "members.user_id": userID}
+	update := bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return r.missOrConflict(ctx, binderID, ErrMemberNotFound)
+	}
+
+	return nil
+}
+
+func (r *repository) SetTags(ctx context.Context, binderID string, tags []string) error {
+	update := bson.M{"$set": bson.M{"tags": tags}}
+	if len(tags) == 0 {
+		update = bson.M{"$unset": bson.M{"tags": ""}}
+	}
+
+	return r.updateByID(ctx, binderID, update)
+}
+
+// SetFolder files the binder into folderID, or back onto the top level of
+// the shelf when folderID is empty.
+func (r *repository) SetFolder(ctx context.Context, binderID, folderID string) error {
+	update := bson.M{"$set": bson.M{"folder_id": folderID}}
+	if folderID == "" {
+		update = bson.M{"$unset": bson.M{"folder_id": ""}}
+	}
+
+	return r.updateByID(ctx, binderID, update)
+}
+
+// MoveFolderContents refiles every binder of userID from one folder into
+// another.
+func (r *repository) MoveFolderContents(ctx context.Context, userID, from, to string) error {
+	filter := bson.M{"user_id": userID, "folder_id": from}
+
+	update := bson.M{"$set": bson.M{"folder_id": to}}
+	if to == "" {
+		update = bson.M{"$unset": bson.M{"folder_id": ""}}
+	}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// SetShelfOrder numbers the given binders of userID from one in order.
+// Binders of other users are left untouched.
+func (r *repository) SetShelfOrder(ctx context.Context, userID string, binderIDs []string) error {
+	writes := make([]mongo.WriteModel, 0, len(binderIDs))
+	for i, id := range binderIDs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "user_id": userID}).
+			SetUpdate(bson.M{"$set": bson.M{"shelf_position": i + 1}}))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *repository) updateByID(ctx context.Context, binderID string, update bson.M) error {
+	res, err := r.collection.UpdateByID(ctx, binderID, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// MigrateLegacyCardIDs rewrites every binder still storing card_ids into
+// entries server-side, so it is safe to run while the API is serving.
+func (r *repository) MigrateLegacyCardIDs(ctx context.Context) (int64, error) {
+	filter := bson.M{"card_ids": bson.M{"$exists": true}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"entries": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$entries", bson.A{}}},
+				bson.M{"$map": bson.M{
+					"input": bson.M{"$filter": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$card_ids", bson.A{}}},
+						"as":    "id",
+						"cond": bson.M{"$not": bson.A{bson.M{"$in": bson.A{
+							"$$id", bson.M{"$ifNull": bson.A{"$entries.card_id", bson.A{}}},
+						}}}},
+					}},
+					"as": "id",
+					"in": bson.M{"card_id": "$$id", "quantity": 1},
+				}},
+			}},
+		}}},
+		{{Key: "$unset", Value: "card_ids"}},
+	}
+
+	res, err := r.collection.UpdateMany(ctx, filter, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// HoldingCards returns the ids of the binders holding any of the cards, as
+// entries or as placeholders.
+func (r *repository) HoldingCards(ctx context.Context, cardIDs []string) ([]string, error) {
+	ids := []string{}
+	if len(cardIDs) == 0 {
+		return ids, nil
+	}
+
+	cur, err := r.collection.Find(ctx, holdingFilter(cardIDs), options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var doc struct {
+			ID string `bson:"_id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID)
+	}
+
+	return ids, cur.Err()
+}
+
+// DetachCards removes the cards from every binder, as entries and as
+// placeholders, and returns how many binders changed.
+func (r *repository) DetachCards(ctx context.Context, cardIDs []string) (int64, error) {
+	if len(cardIDs) == 0 {
+		return 0, nil
+	}
+
+	res, err := r.collection.UpdateMany(ctx, holdingFilter(cardIDs), detachUpdate(cardIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// DetachCardsFrom removes the cards from one binder, as entries and as
+// placeholders.
+func (r *repository) DetachCardsFrom(ctx context.Context, binderID string, cardIDs []string) error {
+	if len(cardIDs) == 0 {
+		return nil
+	}
+
+	filter := holdingFilter(cardIDs)
+	filter["_id"] = binderID
+
+	_, err := r.collection.UpdateOne(ctx, filter, detachUpdate(cardIDs))
+	return err
+}
+
+func detachUpdate(cardIDs []string) bson.M {
+	in := bson.M{"$in": cardIDs}
+	return bson.M{
+		"$pull": bson.M{
+			"entries":      bson.M{"card_id": in},
+			"placeholders": bson.M{"card_id": in},
+		},
+		"$inc": bson.M{"version": 1},
+	}
+}
+
+func holdingFilter(cardIDs []string) bson.M {
+	in := bson.M{"$in": cardIDs}
+	return bson.M{"$or": bson.A{
+		bson.M{"entries.card_id": in},
+		bson.M{"placeholders.card_id": in},
+	}}
+}
+
+// Stats aggregates the contents of every binder matching filter. Smart
+// binders count the cards their query currently matches.
+func (r *repository) Stats(ctx context.Context, filter bson.M) (models.BinderStats, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1, "query": 1}))
+	if err != nil {
+		return models.BinderStats{}, err
+	}
+
+	var matched []models.Binder
+	if err := cursor.All(ctx, &matched); err != nil {
+		return models.BinderStats{}, err
+	}
+
+	static := bson.A{}
+	smart := []models.Binder{}
+	for _, b := range matched {
+		if b.IsSmart() {
+			smart = append(smart, b)
+			continue
+		}
+		static = append(static, b.ID)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$in": static}}}},
+		{{Key: "$unwind", Value: "$entries"}},
+		{{Key: "$replaceWith", Value: "$entries"}},
+	}
+
+	for _, b := range smart {
+		pipeline = append(pipeline, bson.D{{Key: "$unionWith", Value: bson.M{
+			"coll":     r.catalog.Cards.Name(),
+			"pipeline": smartEntries(r.collection.Name(), b),
+		}}})
+	}
+
+	pipeline = append(pipeline, statsStages(r.catalog)...)
+
+	cursor, err = r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.BinderStats{}, err
+	}
+
+	var result []models.BinderStats
+	if err := cursor.All(ctx, &result); err != nil {
+		return models.BinderStats{}, err
+	}
+
+	stats := models.BinderStats{}
+	if len(result) > 0 {
+		stats = result[0]
+	}
+
+	return stats, nil
+}
+
+func (r *repository) missOrConflict(ctx context.Context, binderID string, conflict error) error {
+	n, err := r.collection.CountDocuments(ctx, bson.M{"_id": binderID})
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return conflict
+}
@@ -2,113 +2,690 @@ package binders
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nextlevellabs/go-wise/wise"
 	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/collections"
+	"github.com/seosoojin/dalkom/internal/domain/groups"
+	"github.com/seosoojin/dalkom/internal/domain/idols"
 	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/internal/domain/users"
 	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service interface {
-	Create(ctx context.Context, binder *models.Binder) error
+	Create(ctx context.Context, userID string, binder *models.Binder) error
+	CreateFromTemplate(ctx context.Context, userID string, template models.BinderTemplate) (models.Binder, error)
 
-	GetByUserID(ctx context.Context, userID string, filter map[string][]any, pagination pagination.Page) ([]models.Binder, error)
-	GetByID(ctx context.Context, id string) (models.Binder, error)
-	GetBinderCards(ctx context.Context, id string) ([]models.Card, error)
+	GetByUserID(ctx context.Context, userID string, filter map[string][]any, opts ListOptions, pagination pagination.Page) ([]models.Binder, error)
+	GetPublicByUserID(ctx context.Context, ownerID string, pagination pagination.Page) ([]models.Binder, error)
+	GetByID(ctx context.Context, userID, id string) (models.Binder, error)
+	GetShared(ctx context.Context, token string) (models.Binder, error)
+	GetSharedCards(ctx context.Context, token string, expand cards.Expand) ([]models.BinderCard, error)
+	GetBinderCards(ctx context.Context, userID, id string, expand cards.Expand) ([]models.BinderCard, error)
+	GetBinderPages(ctx context.Context, userID, id string, expand cards.Expand) (models.BinderPages, error)
 
-	Update(ctx context.Context, binder *models.Binder) error
-	AddCard(ctx context.Context, binderID, cardID string) error
-	RemoveCard(ctx context.Context, binderID, cardID string) error
+	GetBinderCompletion(ctx context.Context, userID, binderID, collectionID string, variants models.VariantCounting) (models.Completion, error)
+	GetUserCompletion(ctx context.Context, userID, collectionID string, variants models.VariantCounting) (models.Completion, error)
+	GetBinderStats(ctx context.Context, userID, binderID string) (models.BinderStats, error)
+	GetUserStats(ctx context.Context, userID string) (models.BinderStats, error)
 
-	Delete(ctx context.Context, id string) (models.Binder, error)
+	Update(ctx context.Context, userID string, binder *models.Binder) error
+	AddCard(ctx context.Context, userID, binderID string, entry models.BinderEntry) error
+	UpdateCard(ctx context.Context, userID, binderID string, entry models.BinderEntry) error
+	RemoveCard(ctx context.Context, userID, binderID, cardID string) error
+	Bulk(ctx context.Context, userID, binderID string, ops []models.BulkOperation) (models.BulkReport, error)
+	TransferCards(ctx context.Context, userID, fromID, toID string, cardIDs []string) (models.BulkReport, error)
+
+	MoveCard(ctx context.Context, userID, binderID, cardID string, to models.SlotPosition) error
+	SwapSlots(ctx context.Context, userID, binderID string, a, b models.SlotPosition) error
+	InsertCard(ctx context.Context, userID, binderID, cardID string, at models.SlotPosition) error
+
+	ConvertToStatic(ctx context.Context, userID, binderID string) (models.Binder, error)
+
+	CreateShareToken(ctx context.Context, userID, binderID string) (models.ShareToken, error)
+	RevokeShareToken(ctx context.Context, userID, binderID, token string) error
+
+	Delete(ctx context.Context, userID, id string) (models.Binder, error)
+
+	SetTags(ctx context.Context, userID, binderID string, tags []string) ([]string, error)
+	SetFolder(ctx context.Context, userID, binderID, folderID string) error
+	ReorderShelf(ctx context.Context, userID string, binderIDs []string) error
+
+	GetFolders(ctx context.Context, userID string) ([]models.BinderFolder, error)
+	CreateFolder(ctx context.Context, userID string, folder *models.BinderFolder) error
+	UpdateFolder(ctx context.Context, userID string, folder *models.BinderFolder) error
+	DeleteFolder(ctx context.Context, userID, folderID string) (models.BinderFolder, error)
+
+	GetHistory(ctx context.Context, userID, binderID string, pagination pagination.Page) ([]models.BinderEvent, error)
+	Undo(ctx context.Context, userID, binderID string, n int) (*models.Binder, error)
+	RestoreAt(ctx context.Context, userID, binderID string, at time.Time) (*models.Binder, error)
+
+	AddMember(ctx context.Context, userID, binderID string, invite MemberInvite) (models.BinderMember, error)
+	RemoveMember(ctx context.Context, userID, binderID, memberID string) error
+
+	Export(ctx context.Context, userID, binderID string) ([]models.BinderExportRow, error)
+	Import(ctx context.Context, userID, binderID string, rows []models.BinderExportRow) (models.ImportReport, error)
 }
 
+// maxMutationAttempts bounds how often a version guarded change is retried
+// when another writer got there first.
+const maxMutationAttempts = 3
+
 type service struct {
-	repo     Repository
-	cardRepo cards.Repository
+	repo           Repository
+	cardRepo       cards.Repository
+	collectionRepo collections.Repository
+	idolRepo       idols.Repository
+	groupRepo      groups.Repository
+	historyRepo    HistoryRepository
+	userRepo       users.Repository
+	folderRepo     FolderRepository
+	policy         Policy
+
+	cardService       cards.Service
+	collectionService collections.Service
 }
 
 var _ Service = &service{}
 
-func NewService(repo Repository, cardRepo cards.Repository) *service {
+func NewService(repo Repository, cardRepo cards.Repository, collectionRepo collections.Repository, idolRepo idols.Repository, groupRepo groups.Repository, historyRepo HistoryRepository, userRepo users.Repository, folderRepo FolderRepository, cardService cards.Service, collectionService collections.Service) *service {
 	return &service{
-		repo:     repo,
-		cardRepo: cardRepo,
+		repo:           repo,
+		cardRepo:       cardRepo,
+		collectionRepo: collectionRepo,
+		idolRepo:       idolRepo,
+		groupRepo:      groupRepo,
+		historyRepo:    historyRepo,
+		userRepo:       userRepo,
+		folderRepo:     folderRepo,
+		policy:         NewAccessPolicy(),
+
+		cardService:       cardService,
+		collectionService: collectionService,
+	}
+}
+
+func (s *service) authorize(ctx context.Context, actor Actor, id string, action Action) (models.Binder, error) {
+	binder, err := s.repo.FindOne(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Binder{}, ErrNotFound
+	}
+
+	if err != nil {
+		return models.Binder{}, err
+	}
+
+	return s.check(actor, binder, action)
+}
+
+// check applies the policy and hides owner-only data from everyone else.
+func (s *service) check(actor Actor, binder models.Binder, action Action) (models.Binder, error) {
+	if err := s.policy.Authorize(actor, binder, action); err != nil {
+		return models.Binder{}, err
+	}
+
+	binder.Role = binder.RoleOf(actor.UserID)
+	redact(&binder)
+
+	return binder, nil
+}
+
+// redact hides share tokens from everyone but the owner, and what was paid
+// for cards and where they were bought from everyone but the owner and
+// members. The binder's Role must be set for the reader.
+func redact(binder *models.Binder) {
+	if binder.Role != models.BinderRoleOwner {
+		binder.ShareTokens = nil
+	}
+
+	if binder.Role != "" {
+		return
+	}
+
+	entries := make([]models.BinderEntry, len(binder.Entries))
+	for i, e := range binder.Entries {
+		e.PricePaid = nil
+		e.Currency = ""
+		e.PurchasedAt = nil
+		e.Source = ""
+		entries[i] = e
+	}
+	binder.Entries = entries
+}
+
+func (s *service) authorizeShared(ctx context.Context, token string) (models.Binder, error) {
+	binders, err := s.repo.Search(ctx, map[string][]any{"share_tokens.token": {token}})
+	if err != nil {
+		return models.Binder{}, err
 	}
+
+	if len(binders) == 0 {
+		return models.Binder{}, ErrNotFound
+	}
+
+	binder := binders[0]
+	binder.MigrateLegacyCardIDs()
+
+	return s.check(Actor{ShareToken: token}, binder, ActionRead)
 }
 
-func (s *service) Create(ctx context.Context, binder *models.Binder) error {
+func (s *service) Create(ctx context.Context, userID string, binder *models.Binder) error {
+	if userID == "" {
+		return ErrForbidden
+	}
+
+	if binder.Type == "" {
+		binder.Type = models.DefaultBinderType
+	}
+
+	if _, ok := models.BinderLayouts[binder.Type]; !ok {
+		return ErrInvalidType
+	}
+
+	if binder.Visibility == "" {
+		binder.Visibility = models.BinderVisibilityPrivate
+	}
+
+	if _, ok := models.BinderVisibilities[binder.Visibility]; !ok {
+		return ErrInvalidVisibility
+	}
+
+	if binder.IsSmart() {
+		if err := validateQuery(binder.Query); err != nil {
+			return err
+		}
+
+		if len(binder.Entries) > 0 {
+			return ErrSmartBinder
+		}
+	}
+
+	seen := make(map[string]struct{}, len(binder.Entries))
+	for i := range binder.Entries {
+		entry := &binder.Entries[i]
+		if entry.Quantity == 0 {
+			entry.Quantity = 1
+		}
+
+		if err := validateEntry(*entry); err != nil {
+			return err
+		}
+
+		if _, ok := seen[entry.CardID]; ok {
+			return ErrCardAlreadyInBinder
+		}
+		seen[entry.CardID] = struct{}{}
+
+		entry.AddedAt = time.Now().UTC()
+	}
+
+	if err := validateLayout(binder.Layout(), binder.Entries); err != nil {
+		return err
+	}
+
+	if err := validatePlaceholders(binder.Layout(), binder.Placeholders); err != nil {
+		return err
+	}
+
+	tags, err := normalizeTags(binder.Tags)
+	if err != nil {
+		return err
+	}
+	binder.Tags = tags
+
+	if binder.FolderID != "" {
+		if _, err := s.folder(ctx, userID, binder.FolderID); err != nil {
+			return err
+		}
+	}
+
+	// New binders go to the end of the shelf.
+	owned, err := s.repo.CountDocuments(ctx, map[string][]any{"user_id": {userID}})
+	if err != nil {
+		return err
+	}
+	binder.ShelfPosition = int(owned) + 1
+
 	binder.ID = uuid.NewString()
-	return s.repo.Upsert(ctx, binder.ID, *binder)
+	binder.UserID = userID
+	binder.Version = 0
+	binder.CardIDs = nil
+	binder.ShareTokens = nil
+	binder.Members = nil
+	if err := s.repo.Upsert(ctx, binder.ID, *binder); err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binder.ID, models.BinderEventCreate, nil)
 }
 
-func (s *service) GetByUserID(ctx context.Context, userID string, filter map[string][]any, pagination pagination.Page) ([]models.Binder, error) {
-	queryFilter := map[string][]interface{}{
-		"user_id": {userID},
+// GetByUserID lists the binders a user owns or is a member of, each with the
+// role the user holds.
+func (s *service) GetByUserID(ctx context.Context, userID string, filter map[string][]any, opts ListOptions, pagination pagination.Page) ([]models.Binder, error) {
+	sort, err := sortOption(opts)
+	if err != nil {
+		return nil, err
 	}
 
+	queryFilter := map[string][]interface{}{}
+
 	for k, v := range filter {
 		queryFilter[k] = v
 	}
 
-	return s.repo.Search(ctx, queryFilter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit))
+	if folders, ok := queryFilter["folder_id"]; ok {
+		queryFilter["folder_id"], err = s.folderFilter(ctx, userID, folders, opts.Recursive)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	delete(queryFilter, "user_id")
+	queryFilter["$or"] = []any{bson.A{
+		bson.M{"user_id": userID},
+		bson.M{"members.user_id": userID},
+	}}
+
+	binders, err := s.repo.Search(ctx, queryFilter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(sort))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range binders {
+		binders[i].MigrateLegacyCardIDs()
+		binders[i].Role = binders[i].RoleOf(userID)
+		redact(&binders[i])
+	}
+
+	return binders, nil
+}
+
+func (s *service) GetPublicByUserID(ctx context.Context, ownerID string, pagination pagination.Page) ([]models.Binder, error) {
+	filter := map[string][]any{
+		"user_id":    {ownerID},
+		"visibility": {models.BinderVisibilityPublic},
+	}
+
+	binders, err := s.repo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range binders {
+		binders[i].MigrateLegacyCardIDs()
+		redact(&binders[i])
+	}
+
+	return binders, nil
 }
 
-func (s *service) GetByID(ctx context.Context, id string) (models.Binder, error) {
-	return s.repo.FindOne(ctx, id)
+func (s *service) GetShared(ctx context.Context, token string) (models.Binder, error) {
+	return s.authorizeShared(ctx, token)
 }
 
-func (s *service) Update(ctx context.Context, binder *models.Binder) error {
-	_, err := s.GetByID(ctx, binder.ID)
+func (s *service) GetSharedCards(ctx context.Context, token string, expand cards.Expand) ([]models.BinderCard, error) {
+	binder, err := s.authorizeShared(ctx, token)
 	if err != nil {
+		return nil, err
+	}
+
+	return s.expandedBinderCards(ctx, binder, expand)
+}
+
+func (s *service) CreateShareToken(ctx context.Context, userID, binderID string) (models.ShareToken, error) {
+	if _, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionShare); err != nil {
+		return models.ShareToken{}, err
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return models.ShareToken{}, err
+	}
+
+	token := models.ShareToken{
+		Token:     base64.RawURLEncoding.EncodeToString(b),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.repo.AddShareToken(ctx, binderID, token); err != nil {
+		return models.ShareToken{}, err
+	}
+
+	return token, nil
+}
+
+func (s *service) RevokeShareToken(ctx context.Context, userID, binderID, token string) error {
+	if _, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionShare); err != nil {
 		return err
 	}
-	return s.repo.Upsert(ctx, binder.ID, *binder)
+
+	return s.repo.RemoveShareToken(ctx, binderID, token)
+}
+
+func (s *service) GetByID(ctx context.Context, userID, id string) (models.Binder, error) {
+	return s.authorize(ctx, Actor{UserID: userID}, id, ActionRead)
 }
 
-func (s *service) AddCard(ctx context.Context, binderID, cardID string) error {
-	binder, err := s.GetByID(ctx, binderID)
+func (s *service) Update(ctx context.Context, userID string, binder *models.Binder) error {
+	current, err := s.authorize(ctx, Actor{UserID: userID}, binder.ID, ActionUpdate)
 	if err != nil {
 		return err
 	}
 
-	if binder.CardIDs == nil {
-		binder.CardIDs = &[]string{}
+	if binder.Type != "" {
+		if _, ok := models.BinderLayouts[binder.Type]; !ok {
+			return ErrInvalidType
+		}
 	}
 
-	*binder.CardIDs = append(*binder.CardIDs, cardID)
+	if binder.Visibility != "" && binder.Visibility != current.Visibility {
+		if _, ok := models.BinderVisibilities[binder.Visibility]; !ok {
+			return ErrInvalidVisibility
+		}
+
+		if err := s.policy.Authorize(Actor{UserID: userID}, current, ActionShare); err != nil {
+			return err
+		}
+	}
 
-	return s.repo.Upsert(ctx, binder.ID, binder)
+	if binder.Query != nil {
+		if err := validateQuery(binder.Query); err != nil {
+			return err
+		}
+
+		if !current.IsSmart() && len(current.Entries) > 0 {
+			return ErrInvalidQuery
+		}
+	}
+
+	binder.UserID = current.UserID
+	binder.ShareTokens = nil
+	binder.Members = nil
+	// Card membership only changes through the card and layout operations so
+	// that a stale update cannot overwrite concurrent additions.
+	binder.Entries = nil
+	binder.CardIDs = nil
+	binder.Version = 0
+	binder.CollectionID = ""
+	binder.Placeholders = nil
+	// Tags, folder and shelf position are the owner's and have their own
+	// operations.
+	binder.Tags = nil
+	binder.FolderID = ""
+	binder.ShelfPosition = 0
+	if binder.Type != "" && binder.Type != current.Type {
+		binder.Placeholders = relayoutPlaceholders(current.Layout(), binder.Layout(), current.Placeholders)
+	}
+
+	if err := s.repo.Upsert(ctx, binder.ID, *binder); err != nil {
+		return err
+	}
+
+	if binder.Type != "" && binder.Type != current.Type && !current.IsSmart() {
+		from, to := current.Layout(), binder.Layout()
+		_, err := s.mutateEntries(ctx, userID, binder.ID, func(b models.Binder) ([]models.BinderEntry, error) {
+			return relayout(from, to, b.Entries), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.record(ctx, userID, binder.ID, models.BinderEventUpdate, &current)
+}
+
+// mutateEntries applies fn to the current entries of the binder and stores
+// the result only if nobody changed the binder in between, retrying on
+// conflicts. It returns the binder as it was before the change.
+func (s *service) mutateEntries(ctx context.Context, userID, binderID string, fn func(binder models.Binder) ([]models.BinderEntry, error)) (models.Binder, error) {
+	for attempt := 0; attempt < maxMutationAttempts; attempt++ {
+		binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionUpdate)
+		if err != nil {
+			return models.Binder{}, err
+		}
+
+		if err := notSmart(binder); err != nil {
+			return models.Binder{}, err
+		}
+
+		entries, err := fn(binder)
+		if err != nil {
+			return models.Binder{}, err
+		}
+
+		err = s.repo.ReplaceEntries(ctx, binderID, binder.Version, entries)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+
+		return binder, err
+	}
+
+	return models.Binder{}, ErrConflict
+}
+
+func (s *service) AddCard(ctx context.Context, userID, binderID string, entry models.BinderEntry) error {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionUpdate)
+	if err != nil {
+		return err
+	}
+
+	if err := notSmart(binder); err != nil {
+		return err
+	}
+
+	if entry.Quantity == 0 {
+		entry.Quantity = 1
+	}
+
+	if err := validateEntry(entry); err != nil {
+		return err
+	}
+
+	entry.AddedAt = time.Now().UTC()
+
+	if entry.Position == nil && binder.Placeholder(entry.CardID) == nil {
+		if err := s.repo.AddEntry(ctx, binderID, entry); err != nil {
+			return err
+		}
+
+		return s.record(ctx, userID, binderID, models.BinderEventAddCard, &binder)
+	}
+
+	before, err := s.mutateEntries(ctx, userID, binderID, func(b models.Binder) ([]models.BinderEntry, error) {
+		if b.HasCard(entry.CardID) {
+			return nil, ErrCardAlreadyInBinder
+		}
+
+		placed := entry
+		if placed.Position == nil {
+			placed.Position = placeholderSlot(b, b.Entries, placed.CardID)
+		}
+
+		entries := append(copyEntries(b.Entries), placed)
+		if err := validateLayout(b.Layout(), entries); err != nil {
+			return nil, err
+		}
+
+		return entries, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventAddCard, &before)
 }
 
-func (s *service) RemoveCard(ctx context.Context, binderID, cardID string) error {
-	binder, err := s.GetByID(ctx, binderID)
+func (s *service) UpdateCard(ctx context.Context, userID, binderID string, entry models.BinderEntry) error {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionUpdate)
 	if err != nil {
 		return err
 	}
 
-	if binder.CardIDs == nil {
-		binder.CardIDs = &[]string{}
+	if err := notSmart(binder); err != nil {
+		return err
+	}
+
+	if err := validateEntry(entry); err != nil {
+		return err
 	}
 
-	for i, id := range *binder.CardIDs {
-		if id == cardID {
-			*binder.CardIDs = append((*binder.CardIDs)[:i], (*binder.CardIDs)[i+1:]...)
+	for _, e := range binder.Entries {
+		if e.CardID == entry.CardID {
+			entry.AddedAt = e.AddedAt
+			entry.Position = e.Position
 			break
 		}
 	}
 
-	return s.repo.Upsert(ctx, binder.ID, binder)
+	if err := s.repo.UpdateEntry(ctx, binderID, entry); err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventUpdateCard, &binder)
 }
 
-func (s *service) GetBinderCards(ctx context.Context, id string) ([]models.Card, error) {
-	binder, err := s.GetByID(ctx, id)
+func (s *service) RemoveCard(ctx context.Context, userID, binderID, cardID string) error {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionUpdate)
+	if err != nil {
+		return err
+	}
+
+	if err := notSmart(binder); err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveEntry(ctx, binderID, cardID); err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventRemoveCard, &binder)
+}
+
+func (s *service) MoveCard(ctx context.Context, userID, binderID, cardID string, to models.SlotPosition) error {
+	before, err := s.mutateEntries(ctx, userID, binderID, func(b models.Binder) ([]models.BinderEntry, error) {
+		return moveCard(b.Layout(), b.Entries, cardID, to)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventLayout, &before)
+}
+
+func (s *service) SwapSlots(ctx context.Context, userID, binderID string, a, b models.SlotPosition) error {
+	before, err := s.mutateEntries(ctx, userID, binderID, func(binder models.Binder) ([]models.BinderEntry, error) {
+		return swapSlots(binder.Layout(), binder.Entries, a, b)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventLayout, &before)
+}
+
+// InsertCard places a card at a pocket, shifting the following cards; cards
+// not yet in the binder are added with a single copy.
+func (s *service) InsertCard(ctx context.Context, userID, binderID, cardID string, at models.SlotPosition) error {
+	before, err := s.mutateEntries(ctx, userID, binderID, func(b models.Binder) ([]models.BinderEntry, error) {
+		entries := b.Entries
+		if !b.HasCard(cardID) {
+			entries = append(copyEntries(entries), models.BinderEntry{
+				CardID:   cardID,
+				Quantity: 1,
+				AddedAt:  time.Now().UTC(),
+			})
+		}
+
+		return insertCard(b.Layout(), entries, cardID, at)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.record(ctx, userID, binderID, models.BinderEventLayout, &before)
+}
+
+func validateEntry(entry models.BinderEntry) error {
+	if entry.CardID == "" || entry.Quantity < 1 {
+		return ErrInvalidEntry
+	}
+
+	if entry.Condition != "" {
+		if _, ok := models.CardConditions[entry.Condition]; !ok {
+			return ErrInvalidEntry
+		}
+	}
+
+	if entry.PricePaid != nil && *entry.PricePaid < 0 {
+		return ErrInvalidEntry
+	}
+
+	return nil
+}
+
+func (s *service) GetBinderCards(ctx context.Context, userID, id string, expand cards.Expand) ([]models.BinderCard, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, id, ActionRead)
 	if err != nil {
 		return nil, err
 	}
 
-	cards, err := s.cardRepo.Find(ctx, *binder.CardIDs)
+	return s.expandedBinderCards(ctx, binder, expand)
+}
+
+func (s *service) GetBinderPages(ctx context.Context, userID, id string, expand cards.Expand) (models.BinderPages, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, id, ActionRead)
+	if err != nil {
+		return models.BinderPages{}, err
+	}
+
+	cards, err := s.binderCards(ctx, binder)
+	if err != nil {
+		return models.BinderPages{}, err
+	}
+
+	missing, err := s.missingCards(ctx, binder, cards)
+	if err != nil {
+		return models.BinderPages{}, err
+	}
+
+	pages := arrangePages(binder, cards, missing)
+
+	targets := make([]*models.BinderCard, 0, len(cards)+len(missing))
+	for _, page := range pages.Pages {
+		for _, slot := range page.Slots {
+			if slot != nil {
+				targets = append(targets, slot)
+			}
+		}
+	}
+	for i := range pages.Loose {
+		targets = append(targets, &pages.Loose[i])
+	}
+
+	return pages, s.expandCards(ctx, targets, expand)
+}
+
+func (s *service) binderCards(ctx context.Context, binder models.Binder) ([]models.BinderCard, error) {
+	entries, err := s.resolveEntries(ctx, binder)
+	if err != nil {
+		return nil, err
+	}
+
+	cardIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		cardIDs = append(cardIDs, entry.CardID)
+	}
+
+	cards, err := s.cardRepo.Find(ctx, cardIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -118,14 +695,65 @@ func (s *service) GetBinderCards(ctx context.Context, id string) ([]models.Card,
 		cardsResultMap[card.ID] = card
 	}
 
-	result := make([]models.Card, 0, len(*binder.CardIDs))
-	for _, id := range *binder.CardIDs {
-		result = append(result, cardsResultMap[id])
+	result := make([]models.BinderCard, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, models.BinderCard{
+			BinderEntry: entry,
+			Card:        models.ExpandedCard{Card: cardsResultMap[entry.CardID]},
+			Owned:       true,
+		})
 	}
 
 	return result, nil
 }
 
-func (s *service) Delete(ctx context.Context, id string) (models.Binder, error) {
-	return s.repo.Delete(ctx, id)
+func (s *service) expandedBinderCards(ctx context.Context, binder models.Binder, expand cards.Expand) ([]models.BinderCard, error) {
+	result, err := s.binderCards(ctx, binder)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*models.BinderCard, len(result))
+	for i := range result {
+		targets[i] = &result[i]
+	}
+
+	return result, s.expandCards(ctx, targets, expand)
+}
+
+// expandCards embeds the requested relations in the cards of binderCards in
+// place.
+func (s *service) expandCards(ctx context.Context, binderCards []*models.BinderCard, expand cards.Expand) error {
+	all := make([]models.Card, 0, len(binderCards))
+	for _, c := range binderCards {
+		all = append(all, c.Card.Card)
+	}
+
+	expanded, err := s.cardService.ExpandCards(ctx, all, expand)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range binderCards {
+		c.Card = expanded[i]
+	}
+
+	return nil
+}
+
+func (s *service) Delete(ctx context.Context, userID, id string) (models.Binder, error) {
+	if _, err := s.authorize(ctx, Actor{UserID: userID}, id, ActionDelete); err != nil {
+		return models.Binder{}, err
+	}
+
+	binder, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return models.Binder{}, err
+	}
+
+	if err := s.record(ctx, userID, id, models.BinderEventDelete, &binder); err != nil {
+		return models.Binder{}, err
+	}
+
+	return binder, nil
 }
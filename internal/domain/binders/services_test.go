@@ -0,0 +1,174 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeRepository keeps binders in memory and counts writes. Methods the
+// tests do not reach panic through the nil embedded interface.
+type fakeRepository struct {
+	Repository
+	binders map[string]models.Binder
+	writes  int
+}
+
+func (r *fakeRepository) FindOne(_ context.Context, id string) (models.Binder, error) {
+	binder, ok := r.binders[id]
+	if !ok {
+		return models.Binder{}, mongo.ErrNoDocuments
+	}
+
+	return binder, nil
+}
+
+func (r *fakeRepository) Upsert(_ context.Context, id string, binder models.Binder) error {
+	r.writes++
+	r.binders[id] = binder
+	return nil
+}
+
+func (r *fakeRepository) Delete(_ context.Context, id string) (models.Binder, error) {
+	binder, ok := r.binders[id]
+	if !ok {
+		return models.Binder{}, mongo.ErrNoDocuments
+	}
+
+	r.writes++
+	delete(r.binders, id)
+	return binder, nil
+}
+
+func (r *fakeRepository) AddEntry(_ context.Context, binderID string, entry models.BinderEntry) error {
+	r.writes++
+	binder := r.binders[binderID]
+	binder.Entries = append(binder.Entries, entry)
+	r.binders[binderID] = binder
+	return nil
+}
+
+func (r *fakeRepository) RemoveEntry(_ context.Context, binderID, cardID string) error {
+	r.writes++
+	binder := r.binders[binderID]
+	binder.Entries = nil
+	r.binders[binderID] = binder
+	return nil
+}
+
+type fakeHistory struct {
+	HistoryRepository
+	events []models.BinderEvent
+}
+
+func (h *fakeHistory) Upsert(_ context.Context, _ string, event models.BinderEvent) error {
+	h.events = append(h.events, event)
+	return nil
+}
+
+func newTestService() (*service, *fakeRepository) {
+	repo := &fakeRepository{binders: map[string]models.Binder{
+		"binder": {
+			ID:         "binder",
+			UserID:     "owner",
+			Name:       "Mine",
+			Type:       models.BinderType3x3,
+			Visibility: models.BinderVisibilityPublic,
+			Members: []models.BinderMember{
+				{UserID: "editor", Role: models.BinderRoleEditor},
+				{UserID: "viewer", Role: models.BinderRoleViewer},
+			},
+			Entries: []models.BinderEntry{{CardID: "held", Quantity: 1}},
+		},
+	}}
+
+	return &service{repo: repo, historyRepo: &fakeHistory{}, policy: NewAccessPolicy()}, repo
+}
+
+func TestServiceMutationsRequireAccess(t *testing.T) {
+	ctx := context.Background()
+
+	operations := []struct {
+		name string
+		run  func(s *service, userID, binderID string) error
+	}{
+		{"update", func(s *service, userID, binderID string) error {
+			return s.Update(ctx, userID, &models.Binder{ID: binderID, Name: "Theirs"})
+		}},
+		{"add card", func(s *service, userID, binderID string) error {
+			return s.AddCard(ctx, userID, binderID, models.BinderEntry{CardID: "new"})
+		}},
+		{"remove card", func(s *service, userID, binderID string) error {
+			return s.RemoveCard(ctx, userID, binderID, "held")
+		}},
+		{"delete", func(s *service, userID, binderID string) error {
+			_, err := s.Delete(ctx, userID, binderID)
+			return err
+		}},
+	}
+
+	callers := []struct {
+		name     string
+		userID   string
+		binderID string
+		want     error
+	}{
+		{"stranger", "stranger", "binder", ErrForbidden},
+		{"anonymous", "", "binder", ErrForbidden},
+		{"viewer", "viewer", "binder", ErrForbidden},
+		{"missing binder", "owner", "missing", ErrNotFound},
+	}
+
+	for _, op := range operations {
+		for _, caller := range callers {
+			t.Run(op.name+"/"+caller.name, func(t *testing.T) {
+				s, repo := newTestService()
+
+				if err := op.run(s, caller.userID, caller.binderID); !errors.Is(err, caller.want) {
+					t.Fatalf("got %v, want %v", err, caller.want)
+				}
+
+				if repo.writes != 0 {
+					t.Fatalf("binder written %d times", repo.writes)
+				}
+			})
+		}
+
+		t.Run(op.name+"/owner", func(t *testing.T) {
+			s, repo := newTestService()
+
+			if err := op.run(s, "owner", "binder"); err != nil {
+				t.Fatal(err)
+			}
+
+			if repo.writes == 0 {
+				t.Fatal("binder not written")
+			}
+		})
+	}
+}
+
+func TestServiceUpdateKeepsOwner(t *testing.T) {
+	for _, userID := range []string{"owner", "editor"} {
+		t.Run(userID, func(t *testing.T) {
+			s, repo := newTestService()
+
+			err := s.Update(context.Background(), userID, &models.Binder{ID: "binder", UserID: "stranger", Name: "Renamed"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := repo.binders["binder"]
+			if stored.UserID != "owner" {
+				t.Fatalf("owner changed to %q", stored.UserID)
+			}
+
+			if stored.Name != "Renamed" {
+				t.Fatalf("name not updated: %q", stored.Name)
+			}
+		})
+	}
+}
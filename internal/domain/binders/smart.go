@@ -0,0 +1,126 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+// smartQueryFields are the card fields a smart binder may filter on.
+var smartQueryFields = map[string]struct{}{
+	"name":          {},
+	"type":          {},
+	"group_id":      {},
+	"collection_id": {},
+	"idol_ids":      {},
+}
+
+func validateQuery(query models.CardQuery) error {
+	for field, values := range query {
+		if _, ok := smartQueryFields[field]; !ok {
+			return ErrInvalidQuery
+		}
+
+		if len(values) == 0 {
+			return ErrInvalidQuery
+		}
+	}
+
+	return nil
+}
+
+func notSmart(binder models.Binder) error {
+	if binder.IsSmart() {
+		return ErrSmartBinder
+	}
+
+	return nil
+}
+
+// resolveEntries returns the binder contents, evaluating the saved query of
+// smart binders against the catalog. Stored entries still provide metadata
+// for matching cards.
+func (s *service) resolveEntries(ctx context.Context, binder models.Binder) ([]models.BinderEntry, error) {
+	if !binder.IsSmart() {
+		return binder.Entries, nil
+	}
+
+	filter := make(map[string][]any, len(binder.Query))
+	for field, values := range binder.Query {
+		for _, v := range values {
+			filter[field] = append(filter[field], v)
+		}
+	}
+
+	cards, err := s.cardRepo.Search(ctx, filter, wise.WithSort(map[string]int{"type": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]models.BinderEntry, len(binder.Entries))
+	for _, e := range binder.Entries {
+		stored[e.CardID] = e
+	}
+
+	entries := make([]models.BinderEntry, 0, len(cards))
+	for _, card := range cards {
+		if e, ok := stored[card.ID]; ok {
+			entries = append(entries, e)
+			continue
+		}
+
+		entries = append(entries, models.BinderEntry{CardID: card.ID, Quantity: 1})
+	}
+
+	return entries, nil
+}
+
+// ConvertToStatic freezes the current result of a smart binder into regular
+// entries and drops the query.
+func (s *service) ConvertToStatic(ctx context.Context, userID, binderID string) (models.Binder, error) {
+	for attempt := 0; attempt < maxMutationAttempts; attempt++ {
+		binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionUpdate)
+		if err != nil {
+			return models.Binder{}, err
+		}
+
+		if !binder.IsSmart() {
+			return models.Binder{}, ErrNotSmartBinder
+		}
+
+		entries, err := s.resolveEntries(ctx, binder)
+		if err != nil {
+			return models.Binder{}, err
+		}
+
+		now := time.Now().UTC()
+		for i := range entries {
+			if entries[i].AddedAt.IsZero() {
+				entries[i].AddedAt = now
+			}
+		}
+
+		err = s.repo.ConvertToStatic(ctx, binderID, binder.Version, entries)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+
+		if err != nil {
+			return models.Binder{}, err
+		}
+
+		if err := s.record(ctx, userID, binderID, models.BinderEventConvert, &binder); err != nil {
+			return models.Binder{}, err
+		}
+
+		binder.Query = nil
+		binder.Entries = entries
+		binder.Version++
+		return binder, nil
+	}
+
+	return models.Binder{}, ErrConflict
+}
@@ -0,0 +1,173 @@
+package binders
+
+import (
+	"context"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *service) GetBinderStats(ctx context.Context, userID, binderID string) (models.BinderStats, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionRead)
+	if err != nil {
+		return models.BinderStats{}, err
+	}
+
+	stats, err := s.stats(ctx, bson.M{"_id": binderID})
+	if err != nil {
+		return models.BinderStats{}, err
+	}
+
+	// What the cards cost is private, like the prices of the entries.
+	if binder.Role == "" {
+		stats.Value = nil
+	}
+
+	return stats, nil
+}
+
+func (s *service) GetUserStats(ctx context.Context, userID string) (models.BinderStats, error) {
+	return s.stats(ctx, bson.M{"user_id": userID})
+}
+
+func (s *service) stats(ctx context.Context, filter bson.M) (models.BinderStats, error) {
+	stats, err := s.repo.Stats(ctx, filter)
+	if err != nil {
+		return models.BinderStats{}, err
+	}
+
+	for i, b := range stats.ByType {
+		if label := models.ShortTypesMap[models.CardType(b.Key)]; label != "" {
+			stats.ByType[i].Label = label
+		}
+	}
+
+	for _, buckets := range []*[]models.StatsBucket{&stats.ByGroup, &stats.ByIdol, &stats.ByCollection, &stats.ByType} {
+		if *buckets == nil {
+			*buckets = []models.StatsBucket{}
+		}
+	}
+
+	if stats.Value == nil {
+		stats.Value = []models.ValueTotal{}
+	}
+
+	return stats, nil
+}
+
+// smartEntries runs against the cards collection and yields an entry for every
+// card matching the binder query, keeping the metadata stored for it.
+func smartEntries(bindersCollection string, binder models.Binder) mongo.Pipeline {
+	match := bson.M{}
+	for field, values := range binder.Query {
+		match[field] = bson.M{"$in": values}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": bindersCollection,
+			"let":  bson.M{"card": "$_id"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"_id": binder.ID}}},
+				{{Key: "$unwind", Value: "$entries"}},
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$entries.card_id", "$$card"}}}}},
+				{{Key: "$replaceWith", Value: "$entries"}},
+			},
+			"as": "stored",
+		}}},
+		{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+			bson.M{"card_id": "$_id", "quantity": 1},
+			bson.M{"$first": "$stored"},
+		}}}},
+	}
+}
+
+// statsStages folds a stream of binder entries into a single BinderStats
+// document. Entries of the same card across binders are merged first so
+// duplicates count every copy beyond the first one.
+func statsStages(catalog Catalog) mongo.Pipeline {
+	copies := bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$quantity", 1}}, 1}}
+
+	return mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$card_id",
+			"copies": bson.M{"$sum": copies},
+			"values": bson.M{"$push": bson.M{
+				"currency": bson.M{"$ifNull": bson.A{"$currency", ""}},
+				"amount":   bson.M{"$multiply": bson.A{"$price_paid", copies}},
+			}},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         catalog.Cards.Name(),
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "card",
+		}}},
+		{{Key: "$unwind", Value: "$card"}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":        nil,
+					"cards":      bson.M{"$sum": 1},
+					"copies":     bson.M{"$sum": "$copies"},
+					"duplicates": bson.M{"$sum": bson.M{"$subtract": bson.A{"$copies", 1}}},
+				}},
+			},
+			"by_group":      bucketStages("$card.group_id", catalog.Groups.Name(), "$ref.name"),
+			"by_idol":       append(bson.A{bson.M{"$unwind": "$card.idol_ids"}}, bucketStages("$card.idol_ids", catalog.Idols.Name(), "$ref.stage_name")...),
+			"by_collection": bucketStages("$card.collection_id", catalog.Collections.Name(), "$ref.name"),
+			"by_type":       bucketStages("$card.type", "", "$_id"),
+			"value": bson.A{
+				bson.M{"$unwind": "$values"},
+				bson.M{"$match": bson.M{"values.amount": bson.M{"$type": "number"}}},
+				bson.M{"$group": bson.M{
+					"_id":   "$values.currency",
+					"total": bson.M{"$sum": "$values.amount"},
+					"cards": bson.M{"$sum": 1},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "currency": "$_id", "total": 1, "cards": 1}},
+				bson.M{"$sort": bson.M{"currency": 1}},
+			},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"cards":         bson.M{"$ifNull": bson.A{bson.M{"$first": "$totals.cards"}, 0}},
+			"copies":        bson.M{"$ifNull": bson.A{bson.M{"$first": "$totals.copies"}, 0}},
+			"duplicates":    bson.M{"$ifNull": bson.A{bson.M{"$first": "$totals.duplicates"}, 0}},
+			"by_group":      1,
+			"by_idol":       1,
+			"by_collection": 1,
+			"by_type":       1,
+			"value":         1,
+		}}},
+	}
+}
+
+// bucketStages groups cards by key, labelling each bucket with a field of
+// the referenced document. An empty from skips the lookup.
+func bucketStages(key, from, label string) bson.A {
+	stages := bson.A{
+		bson.M{"$group": bson.M{
+			"_id":    key,
+			"cards":  bson.M{"$sum": 1},
+			"copies": bson.M{"$sum": "$copies"},
+		}},
+	}
+
+	var labelExpr any = label
+	if from != "" {
+		stages = append(stages, bson.M{"$lookup": bson.M{
+			"from":         from,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "ref",
+		}})
+		labelExpr = bson.M{"$ifNull": bson.A{bson.M{"$first": label}, "$_id"}}
+	}
+
+	return append(stages,
+		bson.M{"$project": bson.M{"_id": 0, "key": "$_id", "label": labelExpr, "cards": 1, "copies": 1}},
+		bson.M{"$sort": bson.D{{Key: "copies", Value: -1}, {Key: "key", Value: 1}}},
+	)
+}
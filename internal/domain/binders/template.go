@@ -0,0 +1,234 @@
+package binders
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/seosoojin/dalkom/internal/domain/collections"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+// CreateFromTemplate creates an empty checklist binder for a collection with
+// a placeholder pocket for each of its cards, ordered by idol and card type.
+func (s *service) CreateFromTemplate(ctx context.Context, userID string, template models.BinderTemplate) (models.Binder, error) {
+	collection, err := s.collectionService.GetCollection(ctx, template.CollectionID)
+	if errors.Is(err, collections.ErrNotFound) {
+		return models.Binder{}, ErrCollectionNotFound
+	}
+
+	if err != nil {
+		return models.Binder{}, err
+	}
+
+	cards, err := s.cardService.GetCards(ctx, map[string][]any{"collection_id": {collection.ID}}, pagination.Page{Limit: -1})
+	if err != nil {
+		return models.Binder{}, err
+	}
+
+	if err := s.sortTemplateCards(ctx, cards); err != nil {
+		return models.Binder{}, err
+	}
+
+	binder := models.Binder{
+		Name:         template.Name,
+		Description:  template.Description,
+		ImageURL:     template.ImageURL,
+		Type:         template.Type,
+		Visibility:   template.Visibility,
+		CollectionID: collection.ID,
+	}
+
+	if binder.Name == "" {
+		binder.Name = collection.Name
+	}
+
+	if binder.Type == "" {
+		binder.Type = models.DefaultBinderType
+	}
+
+	if _, ok := models.BinderLayouts[binder.Type]; !ok {
+		return models.Binder{}, ErrInvalidType
+	}
+
+	layout := binder.Layout()
+	binder.Placeholders = make([]models.BinderPlaceholder, 0, len(cards))
+	for i, card := range cards {
+		binder.Placeholders = append(binder.Placeholders, models.BinderPlaceholder{
+			CardID:   card.ID,
+			Position: slotPosition(layout, i),
+		})
+	}
+
+	if err := s.Create(ctx, userID, &binder); err != nil {
+		return models.Binder{}, err
+	}
+
+	return binder, nil
+}
+
+// sortTemplateCards orders cards by the stage name of their idol, then by
+// card type and name. Cards featuring several idols or none come last.
+func (s *service) sortTemplateCards(ctx context.Context, cards []models.Card) error {
+	idolIDs := []string{}
+	seen := map[string]struct{}{}
+	for _, card := range cards {
+		for _, id := range card.IdolIDs {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				idolIDs = append(idolIDs, id)
+			}
+		}
+	}
+
+	idols, err := s.idolRepo.Find(ctx, idolIDs)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string, len(idols))
+	for _, idol := range idols {
+		names[idol.ID] = idol.StageName
+	}
+
+	typeOrder := make(map[models.CardType]int, len(models.CardTypes))
+	for i, t := range models.CardTypes {
+		typeOrder[t] = i
+	}
+
+	idolKey := func(card models.Card) (bool, string) {
+		if len(card.IdolIDs) != 1 {
+			return true, ""
+		}
+
+		return false, names[card.IdolIDs[0]]
+	}
+
+	typeKey := func(card models.Card) int {
+		if i, ok := typeOrder[card.Type]; ok {
+			return i
+		}
+
+		return len(typeOrder)
+	}
+
+	sort.SliceStable(cards, func(i, j int) bool {
+		groupI, idolI := idolKey(cards[i])
+		groupJ, idolJ := idolKey(cards[j])
+
+		if groupI != groupJ {
+			return !groupI
+		}
+
+		if idolI != idolJ {
+			return idolI < idolJ
+		}
+
+		if typeKey(cards[i]) != typeKey(cards[j]) {
+			return typeKey(cards[i]) < typeKey(cards[j])
+		}
+
+		return cards[i].Name < cards[j].Name
+	})
+
+	return nil
+}
+
+func validatePlaceholders(layout models.PageLayout, placeholders []models.BinderPlaceholder) error {
+	cards := make(map[string]struct{}, len(placeholders))
+	pockets := make(map[int]struct{}, len(placeholders))
+
+	for _, p := range placeholders {
+		if err := validatePosition(layout, p.Position); err != nil {
+			return err
+		}
+
+		idx := slotIndex(layout, p.Position)
+		if _, ok := pockets[idx]; ok {
+			return ErrSlotOccupied
+		}
+		pockets[idx] = struct{}{}
+
+		if _, ok := cards[p.CardID]; ok || p.CardID == "" {
+			return ErrInvalidEntry
+		}
+		cards[p.CardID] = struct{}{}
+	}
+
+	return nil
+}
+
+// placeholderSlot returns the pocket reserved for cardID while it is still
+// free, so cards added without a position land where the template expects.
+func placeholderSlot(binder models.Binder, entries []models.BinderEntry, cardID string) *models.SlotPosition {
+	pos := binder.Placeholder(cardID)
+	if pos == nil {
+		return nil
+	}
+
+	layout := binder.Layout()
+	if _, ok := occupancy(layout, entries)[slotIndex(layout, *pos)]; ok {
+		return nil
+	}
+
+	return pos
+}
+
+func relayoutPlaceholders(from, to models.PageLayout, placeholders []models.BinderPlaceholder) []models.BinderPlaceholder {
+	out := make([]models.BinderPlaceholder, len(placeholders))
+
+	for i, p := range placeholders {
+		out[i] = models.BinderPlaceholder{
+			CardID:   p.CardID,
+			Position: slotPosition(to, slotIndex(from, p.Position)),
+		}
+	}
+
+	return out
+}
+
+// missingCards returns the placeholder pockets of cards the binder does not
+// hold yet.
+func (s *service) missingCards(ctx context.Context, binder models.Binder, owned []models.BinderCard) ([]models.BinderCard, error) {
+	if len(binder.Placeholders) == 0 {
+		return nil, nil
+	}
+
+	held := make(map[string]struct{}, len(owned))
+	for _, c := range owned {
+		held[c.CardID] = struct{}{}
+	}
+
+	missing := []models.BinderPlaceholder{}
+	cardIDs := []string{}
+	for _, p := range binder.Placeholders {
+		if _, ok := held[p.CardID]; ok {
+			continue
+		}
+
+		missing = append(missing, p)
+		cardIDs = append(cardIDs, p.CardID)
+	}
+
+	cards, err := s.cardRepo.Find(ctx, cardIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.Card, len(cards))
+	for _, card := range cards {
+		byID[card.ID] = card
+	}
+
+	result := make([]models.BinderCard, 0, len(missing))
+	for _, p := range missing {
+		pos := p.Position
+		result = append(result, models.BinderCard{
+			BinderEntry: models.BinderEntry{CardID: p.CardID, Position: &pos},
+			Card:        models.ExpandedCard{Card: byID[p.CardID]},
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,607 @@
+package binders
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch strings.ToLower(format) {
+	case "", "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+const (
+	// maxImportRows bounds an import like maxBulkOperations bounds a bulk
+	// request.
+	maxImportRows = maxBulkOperations
+	// maxImportSize caps how much of an import file is read.
+	maxImportSize = 1 << 20
+)
+
+var csvHeader = []string{
+	"card_id", "name", "short_name", "group", "idols", "collection", "type",
+	"quantity", "condition", "price_paid", "currency", "purchased_at", "source",
+	"page", "slot",
+}
+
+// csvListSeparator joins multi-valued cells such as idol names.
+const csvListSeparator = "|"
+
+// Export flattens a binder into rows enriched with catalog names, using one
+// lookup per related resource.
+func (s *service) Export(ctx context.Context, userID, binderID string) ([]models.BinderExportRow, error) {
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := s.binderCards(ctx, binder)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs, idolIDs, collectionIDs := []string{}, []string{}, []string{}
+	for _, c := range cards {
+		groupIDs = append(groupIDs, c.Card.GroupID)
+		collectionIDs = append(collectionIDs, c.Card.CollectionID)
+		idolIDs = append(idolIDs, c.Card.IdolIDs...)
+	}
+
+	groups, err := s.groupRepo.Find(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	idols, err := s.idolRepo.Find(ctx, idolIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := s.collectionRepo.Find(ctx, collectionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groupNames := make(map[string]string, len(groups))
+	for _, g := range groups {
+		groupNames[g.ID] = g.Name
+	}
+
+	idolNames := make(map[string]string, len(idols))
+	for _, i := range idols {
+		idolNames[i.ID] = idolLabel(i)
+	}
+
+	collectionNames := make(map[string]string, len(collections))
+	for _, c := range collections {
+		collectionNames[c.ID] = c.Name
+	}
+
+	rows := make([]models.BinderExportRow, 0, len(cards))
+	for _, c := range cards {
+		row := models.BinderExportRow{
+			CardID:      c.CardID,
+			Name:        c.Card.Name,
+			ShortName:   c.Card.ShortName,
+			Group:       groupNames[c.Card.GroupID],
+			Idols:       []string{},
+			Collection:  collectionNames[c.Card.CollectionID],
+			Type:        c.Card.Type,
+			Quantity:    c.Quantity,
+			Condition:   c.Condition,
+			PricePaid:   c.PricePaid,
+			Currency:    c.Currency,
+			PurchasedAt: c.PurchasedAt,
+			Source:      c.Source,
+		}
+
+		for _, id := range c.Card.IdolIDs {
+			row.Idols = append(row.Idols, idolNames[id])
+		}
+
+		if c.Position != nil {
+			page, slot := c.Position.Page, c.Position.Slot
+			row.Page, row.Slot = &page, &slot
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+type rowMatch struct {
+	row   int
+	data  models.BinderExportRow
+	entry models.BinderEntry
+}
+
+// Import adds the rows to a binder in a single change. Rows are matched to
+// catalog cards by id or by name, collection and idols; rows that match
+// nothing, or more than one card, are reported instead of imported.
+func (s *service) Import(ctx context.Context, userID, binderID string, rows []models.BinderExportRow) (models.ImportReport, error) {
+	if len(rows) > maxImportRows {
+		return models.ImportReport{}, ErrTooManyRows
+	}
+
+	binder, err := s.authorize(ctx, Actor{UserID: userID}, binderID, ActionUpdate)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	if err := notSmart(binder); err != nil {
+		return models.ImportReport{}, err
+	}
+
+	matches, unmatched, err := s.matchRows(ctx, rows)
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	report := models.ImportReport{Unmatched: unmatched}
+
+	before, err := s.mutateEntries(ctx, userID, binderID, func(b models.Binder) ([]models.BinderEntry, error) {
+		report.Imported = 0
+		report.Skipped = []models.ImportIssue{}
+
+		layout := b.Layout()
+		entries := copyEntries(b.Entries)
+		taken := occupancy(layout, entries)
+
+		for _, m := range matches {
+			if findEntry(entries, m.entry.CardID) >= 0 {
+				report.Skipped = append(report.Skipped, models.ImportIssue{Row: m.row, Reason: ErrCardAlreadyInBinder.Error(), Data: m.data})
+				continue
+			}
+
+			entry := m.entry
+			if entry.Position == nil {
+				entry.Position = placeholderSlot(b, entries, entry.CardID)
+			}
+
+			if entry.Position != nil {
+				idx := slotIndex(layout, *entry.Position)
+				if _, ok := taken[idx]; ok || validatePosition(layout, *entry.Position) != nil {
+					entry.Position = nil
+				} else {
+					taken[idx] = len(entries)
+				}
+			}
+
+			entries = append(entries, entry)
+			report.Imported++
+		}
+
+		return entries, nil
+	})
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	if err := s.record(ctx, userID, binderID, models.BinderEventImport, &before); err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return report, nil
+}
+
+func (s *service) matchRows(ctx context.Context, rows []models.BinderExportRow) ([]rowMatch, []models.ImportIssue, error) {
+	matches := []rowMatch{}
+	unmatched := []models.ImportIssue{}
+
+	byID := []string{}
+	byName := false
+	for _, row := range rows {
+		if row.CardID != "" {
+			byID = append(byID, row.CardID)
+		} else {
+			byName = true
+		}
+	}
+
+	known, err := s.cardRepo.Find(ctx, byID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	knownIDs := make(map[string]struct{}, len(known))
+	for _, c := range known {
+		knownIDs[c.ID] = struct{}{}
+	}
+
+	var catalog *importCatalog
+	if byName {
+		catalog, err = s.loadImportCatalog(ctx, rows)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i, row := range rows {
+		n := i + 1
+
+		cardID := row.CardID
+		if cardID != "" {
+			if _, ok := knownIDs[cardID]; !ok {
+				unmatched = append(unmatched, models.ImportIssue{Row: n, Reason: "unknown card id", Data: row})
+				continue
+			}
+		} else {
+			found, reason := catalog.match(row)
+			if found == "" {
+				unmatched = append(unmatched, models.ImportIssue{Row: n, Reason: reason, Data: row})
+				continue
+			}
+			cardID = found
+		}
+
+		entry := models.BinderEntry{
+			CardID:      cardID,
+			Quantity:    row.Quantity,
+			Condition:   row.Condition,
+			PricePaid:   row.PricePaid,
+			Currency:    row.Currency,
+			PurchasedAt: row.PurchasedAt,
+			Source:      row.Source,
+			AddedAt:     time.Now().UTC(),
+		}
+
+		if entry.Quantity == 0 {
+			entry.Quantity = 1
+		}
+
+		if row.Page != nil && row.Slot != nil {
+			entry.Position = &models.SlotPosition{Page: *row.Page, Slot: *row.Slot}
+		}
+
+		if err := validateEntry(entry); err != nil {
+			unmatched = append(unmatched, models.ImportIssue{Row: n, Reason: err.Error(), Data: row})
+			continue
+		}
+
+		matches = append(matches, rowMatch{row: n, data: row, entry: entry})
+	}
+
+	return matches, unmatched, nil
+}
+
+// importCatalog is the slice of the catalog needed to match rows by name.
+type importCatalog struct {
+	collections map[string][]models.Collection
+	groupNames  map[string]string
+	cards       map[string][]models.Card
+	idolNames   map[string][]string
+}
+
+func (s *service) loadImportCatalog(ctx context.Context, rows []models.BinderExportRow) (*importCatalog, error) {
+	catalog := &importCatalog{
+		collections: map[string][]models.Collection{},
+		groupNames:  map[string]string{},
+		cards:       map[string][]models.Card{},
+		idolNames:   map[string][]string{},
+	}
+
+	wanted := map[string]struct{}{}
+	for _, row := range rows {
+		if row.CardID == "" {
+			wanted[normalizeName(row.Collection)] = struct{}{}
+		}
+	}
+
+	collections, err := s.collectionRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionIDs := []any{}
+	for _, c := range collections {
+		key := normalizeName(c.Name)
+		if _, ok := wanted[key]; !ok {
+			continue
+		}
+		catalog.collections[key] = append(catalog.collections[key], c)
+		collectionIDs = append(collectionIDs, c.ID)
+	}
+
+	if len(collectionIDs) == 0 {
+		return catalog, nil
+	}
+
+	groups, err := s.groupRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range groups {
+		catalog.groupNames[g.ID] = normalizeName(g.Name)
+	}
+
+	cards, err := s.cardRepo.Search(ctx, map[string][]any{"collection_id": collectionIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	idolIDs := []string{}
+	for _, c := range cards {
+		catalog.cards[c.CollectionID] = append(catalog.cards[c.CollectionID], c)
+		idolIDs = append(idolIDs, c.IdolIDs...)
+	}
+
+	idols, err := s.idolRepo.Find(ctx, idolIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range idols {
+		catalog.idolNames[i.ID] = []string{normalizeName(i.StageName), normalizeName(i.Name)}
+	}
+
+	return catalog, nil
+}
+
+func (c *importCatalog) match(row models.BinderExportRow) (string, string) {
+	if row.Name == "" || row.Collection == "" {
+		return "", "card_id or name and collection are required"
+	}
+
+	name := normalizeName(row.Name)
+	found := []string{}
+
+	for _, collection := range c.collections[normalizeName(row.Collection)] {
+		if row.Group != "" && c.groupNames[collection.GroupID] != normalizeName(row.Group) {
+			continue
+		}
+
+		for _, card := range c.cards[collection.ID] {
+			if normalizeName(card.Name) != name && normalizeName(card.ShortName) != name {
+				continue
+			}
+
+			if row.Type != "" && card.Type != row.Type {
+				continue
+			}
+
+			if len(row.Idols) > 0 && !c.sameIdols(card.IdolIDs, row.Idols) {
+				continue
+			}
+
+			found = append(found, card.ID)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", "no matching card"
+	case 1:
+		return found[0], ""
+	default:
+		return "", "ambiguous: matches several cards"
+	}
+}
+
+func (c *importCatalog) sameIdols(idolIDs []string, names []string) bool {
+	if len(idolIDs) != len(names) {
+		return false
+	}
+
+	for _, name := range names {
+		name = normalizeName(name)
+		ok := false
+		for _, id := range idolIDs {
+			for _, known := range c.idolNames[id] {
+				if known != "" && known == name {
+					ok = true
+				}
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func idolLabel(idol models.Idol) string {
+	if idol.StageName != "" {
+		return idol.StageName
+	}
+
+	return idol.Name
+}
+
+func EncodeRows(w io.Writer, format Format, rows []models.BinderExportRow) error {
+	if format == FormatJSON {
+		return json.NewEncoder(w).Encode(rows)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.CardID, row.Name, row.ShortName, row.Group,
+			strings.Join(row.Idols, csvListSeparator), row.Collection, string(row.Type),
+			strconv.Itoa(row.Quantity), string(row.Condition), "", row.Currency, "", row.Source,
+			"", "",
+		}
+
+		if row.PricePaid != nil {
+			record[9] = strconv.FormatFloat(*row.PricePaid, 'f', -1, 64)
+		}
+
+		if row.PurchasedAt != nil {
+			record[11] = row.PurchasedAt.Format(time.RFC3339)
+		}
+
+		if row.Page != nil && row.Slot != nil {
+			record[13] = strconv.Itoa(*row.Page)
+			record[14] = strconv.Itoa(*row.Slot)
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// DecodeRows reads rows in the given format. CSV columns are matched by
+// header name, so spreadsheets only need the columns they have. Files with
+// more than maxImportRows rows are refused.
+func DecodeRows(r io.Reader, format Format) ([]models.BinderExportRow, error) {
+	if format == FormatJSON {
+		rows := []models.BinderExportRow{}
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err)
+		}
+
+		if len(rows) > maxImportRows {
+			return nil, ErrTooManyRows
+		}
+
+		return rows, nil
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return []models.BinderExportRow{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeName(name)] = i
+	}
+
+	rows := []models.BinderExportRow{}
+	for i := 1; ; i++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidImport, err)
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, ErrTooManyRows
+		}
+
+		row, err := decodeCSVRow(columns, record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %s", ErrInvalidImport, i, err)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func decodeCSVRow(columns map[string]int, record []string) (models.BinderExportRow, error) {
+	get := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := models.BinderExportRow{
+		CardID:     get("card_id"),
+		Name:       get("name"),
+		ShortName:  get("short_name"),
+		Group:      get("group"),
+		Idols:      []string{},
+		Collection: get("collection"),
+		Type:       models.CardType(get("type")),
+		Condition:  models.CardCondition(get("condition")),
+		Currency:   get("currency"),
+		Source:     get("source"),
+	}
+
+	if v := get("idols"); v != "" {
+		for _, idol := range strings.Split(v, csvListSeparator) {
+			row.Idols = append(row.Idols, strings.TrimSpace(idol))
+		}
+	}
+
+	if v := get("quantity"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return row, err
+		}
+		row.Quantity = n
+	}
+
+	if v := get("price_paid"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return row, err
+		}
+		row.PricePaid = &f
+	}
+
+	if v := get("purchased_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, v)
+		}
+		if err != nil {
+			return row, err
+		}
+		row.PurchasedAt = &t
+	}
+
+	if page, slot := get("page"), get("slot"); page != "" && slot != "" {
+		p, err := strconv.Atoi(page)
+		if err != nil {
+			return row, err
+		}
+
+		s, err := strconv.Atoi(slot)
+		if err != nil {
+			return row, err
+		}
+
+		row.Page, row.Slot = &p, &s
+	}
+
+	return row, nil
+}
@@ -0,0 +1,13 @@
+package cards
+
+import "errors"
+
+var (
+	ErrNotFound       = errors.New("card not found")
+	ErrInvalidExpand  = errors.New("invalid expand")
+	ErrInvalidVariant = errors.New("invalid card variant")
+	ErrBaseNotFound   = errors.New("base card not found")
+	// ErrInvalidReference is wrapped with the group, collection or idol that
+	// does not exist.
+	ErrInvalidReference = errors.New("invalid reference")
+)
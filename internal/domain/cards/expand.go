@@ -0,0 +1,125 @@
+package cards
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+// Expand names the related resources to embed in card listings, read from
+// expand=group,idols,collection.
+type Expand struct {
+	Group      bool
+	Idols      bool
+	Collection bool
+}
+
+func NewExpandFromRequest(r *http.Request) (Expand, error) {
+	var expand Expand
+
+	for _, raw := range r.URL.Query()["expand"] {
+		for _, name := range strings.Split(raw, ",") {
+			switch strings.TrimSpace(name) {
+			case "group":
+				expand.Group = true
+			case "idols":
+				expand.Idols = true
+			case "collection":
+				expand.Collection = true
+			case "":
+			default:
+				return Expand{}, fmt.Errorf("%w: unknown relation %q", ErrInvalidExpand, name)
+			}
+		}
+	}
+
+	return expand, nil
+}
+
+// ExpandCards embeds the requested relations in cards, with one query per
+// relation whatever the number of cards.
+func (s *service) ExpandCards(ctx context.Context, cards []models.Card, expand Expand) ([]models.ExpandedCard, error) {
+	expanded := make([]models.ExpandedCard, len(cards))
+	for i, card := range cards {
+		expanded[i].Card = card
+	}
+
+	if expand.Group {
+		groups, err := s.groupRepo.Find(ctx, relatedIDs(cards, func(c models.Card) []string { return []string{c.GroupID} }))
+		if err != nil {
+			return nil, err
+		}
+
+		byID := make(map[string]models.Group, len(groups))
+		for _, group := range groups {
+			byID[group.ID] = group
+		}
+
+		for i := range expanded {
+			if group, ok := byID[expanded[i].GroupID]; ok {
+				expanded[i].Group = &group
+			}
+		}
+	}
+
+	if expand.Idols {
+		idols, err := s.idolRepo.Find(ctx, relatedIDs(cards, func(c models.Card) []string { return c.IdolIDs }))
+		if err != nil {
+			return nil, err
+		}
+
+		byID := make(map[string]models.Idol, len(idols))
+		for _, idol := range idols {
+			byID[idol.ID] = idol
+		}
+
+		for i := range expanded {
+			expanded[i].Idols = []models.Idol{}
+			for _, id := range expanded[i].IdolIDs {
+				if idol, ok := byID[id]; ok {
+					expanded[i].Idols = append(expanded[i].Idols, idol)
+				}
+			}
+		}
+	}
+
+	if expand.Collection {
+		collections, err := s.collectionRepo.Find(ctx, relatedIDs(cards, func(c models.Card) []string { return []string{c.CollectionID} }))
+		if err != nil {
+			return nil, err
+		}
+
+		byID := make(map[string]models.Collection, len(collections))
+		for _, collection := range collections {
+			byID[collection.ID] = collection
+		}
+
+		for i := range expanded {
+			if collection, ok := byID[expanded[i].CollectionID]; ok {
+				expanded[i].Collection = &collection
+			}
+		}
+	}
+
+	return expanded, nil
+}
+
+func relatedIDs(cards []models.Card, ids func(models.Card) []string) []string {
+	seen := map[string]struct{}{}
+	out := []string{}
+
+	for _, card := range cards {
+		for _, id := range ids(card) {
+			if _, ok := seen[id]; ok || id == "" {
+				continue
+			}
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
@@ -2,37 +2,62 @@ package cards
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/filters"
 	"github.com/seosoojin/dalkom/internal/domain/handlers"
+	"github.com/seosoojin/dalkom/internal/domain/names"
 	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/internal/gateways/middlewares"
 	"github.com/seosoojin/dalkom/pkg/models"
 )
 
 type Handler interface {
 	GetCards(w http.ResponseWriter, r *http.Request)
+	GetByID(w http.ResponseWriter, r *http.Request)
+	CreateCard(w http.ResponseWriter, r *http.Request)
+	UpdateCard(w http.ResponseWriter, r *http.Request)
+	PatchCard(w http.ResponseWriter, r *http.Request)
+	DeleteCard(w http.ResponseWriter, r *http.Request)
 	handlers.Http
 }
 
 type handler struct {
-	service Service
+	service        Service
+	authMiddleware middlewares.Authenticator
 }
 
 var _ Handler = &handler{}
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, authMiddleware middlewares.Authenticator) *handler {
 	return &handler{
-		service: service,
+		service:        service,
+		authMiddleware: authMiddleware,
 	}
 }
 
 func (h *handler) RegisterRoutes(r *chi.Mux) {
 	r.Get("/cards", h.GetCards)
 	r.Get("/cards/{id}", h.GetByID)
-	r.Post("/cards", h.CreateCard)
+	r.Get("/cards/{id}/variants", h.GetVariants)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionEditCatalog))
+		r.Post("/cards", h.CreateCard)
+		r.Put("/cards/{id}", h.UpdateCard)
+		r.Patch("/cards/{id}", h.PatchCard)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionDeleteCatalog))
+		r.Delete("/cards/{id}", h.DeleteCard)
+	})
 }
 
 func (h *handler) GetCards(w http.ResponseWriter, r *http.Request) {
@@ -48,13 +73,25 @@ func (h *handler) GetCards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expand, err := NewExpandFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	cards, err := h.service.GetCards(r.Context(), filter, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	render.JSON(w, r, cards)
+	expanded, err := h.service.ExpandCards(r.Context(), cards, expand)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, expanded)
 }
 
 func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -62,7 +99,7 @@ func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	card, err := h.service.GetEnrichedCard(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), httpStatus(err))
 		return
 	}
 
@@ -70,6 +107,12 @@ func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) CreateCard(w http.ResponseWriter, r *http.Request) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	card := new(models.Card)
 
 	b, err := io.ReadAll(r.Body)
@@ -84,28 +127,125 @@ func (h *handler) CreateCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.CreateCard(r.Context(), card); err != nil {
+	if err := h.service.CreateCard(r.Context(), card, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, card)
+}
+
+func (h *handler) UpdateCard(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, new(models.Card))
+}
+
+func (h *handler) PatchCard(w http.ResponseWriter, r *http.Request) {
+	card, err := h.service.GetCard(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.update(w, r, &card)
+}
+
+func (h *handler) update(w http.ResponseWriter, r *http.Request, card *models.Card) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	err = json.Unmarshal(b, card)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card.ID = chi.URLParam(r, "id")
+	if err := h.service.UpdateCard(r.Context(), card, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
 	render.JSON(w, r, card)
 }
 
+func (h *handler) GetVariants(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	variants, err := h.service.GetVariants(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, variants)
+}
+
+func (h *handler) DeleteCard(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	opts, err := deletion.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.DeleteCard(r.Context(), id, opts)
+	switch {
+	case errors.Is(err, deletion.ErrReferenced):
+		render.Status(r, http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+var cardTypes = func() []string {
+	types := make([]string, 0, len(models.CardTypes))
+	for _, t := range models.CardTypes {
+		types = append(types, string(t))
+	}
+	return types
+}()
+
+// cardFilters lists the card fields clients may filter on.
+var cardFilters = filters.Schema{
+	"name":          {Kind: filters.String},
+	"type":          {Kind: filters.String, Enum: cardTypes, Ops: filters.ReferenceOps},
+	"group_id":      {Kind: filters.String, Ops: filters.ReferenceOps},
+	"collection_id": {Kind: filters.String, Ops: filters.ReferenceOps},
+	"idol_ids":      {Kind: filters.String, Many: true, Ops: filters.ReferenceOps},
+	"base_card_id":  {Kind: filters.String, Ops: filters.ReferenceOps},
+}
+
 func (h *handler) parseFilter(r *http.Request) (map[string][]any, error) {
-	filter := map[string][]any{}
+	return cardFilters.Parse(r.URL.Query(), "offset", "limit", "expand")
+}
 
-	values := r.URL.Query()
-	for key, value := range values {
-		if key == "offset" || key == "limit" {
-			continue
-		}
-		filter[key] = []any{}
+var errorStatuses = map[error]int{
+	ErrNotFound:         http.StatusNotFound,
+	ErrInvalidVariant:   http.StatusBadRequest,
+	ErrBaseNotFound:     http.StatusBadRequest,
+	ErrInvalidExpand:    http.StatusBadRequest,
+	ErrInvalidReference: http.StatusBadRequest,
+}
 
-		for _, v := range value {
-			filter[key] = append(filter[key], v)
+func httpStatus(err error) int {
+	for target, status := range errorStatuses {
+		if errors.Is(err, target) {
+			return status
 		}
 	}
 
-	return filter, nil
+	return http.StatusInternalServerError
 }
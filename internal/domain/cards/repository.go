@@ -1,8 +1,50 @@
 package cards
 
 import (
+	"context"
+
 	"github.com/nextlevellabs/go-wise/wise"
 	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type Repository wise.MongoRepository[models.Card]
+type Repository interface {
+	wise.MongoRepository[models.Card]
+
+	Replace(ctx context.Context, card models.Card) error
+}
+
+type repository struct {
+	wise.MongoRepository[models.Card]
+	collection *mongo.Collection
+}
+
+var _ Repository = &repository{}
+
+func NewRepository(col *mongo.Collection) (*repository, error) {
+	repo, err := wise.NewMongoSimpleRepository[models.Card](col)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		MongoRepository: repo,
+		collection:      col,
+	}, nil
+}
+
+// Replace overwrites the stored card. Unlike Upsert it also clears the
+// fields the card leaves empty, such as the base card of a former variant.
+func (r *repository) Replace(ctx context.Context, card models.Card) error {
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"_id": card.ID}, card)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -2,28 +2,40 @@ package cards
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/nextlevellabs/go-wise/wise"
 	"github.com/seosoojin/dalkom/internal/domain/collections"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
 	"github.com/seosoojin/dalkom/internal/domain/groups"
 	"github.com/seosoojin/dalkom/internal/domain/idols"
+	"github.com/seosoojin/dalkom/internal/domain/names"
 	"github.com/seosoojin/dalkom/internal/domain/pagination"
 	"github.com/seosoojin/dalkom/pkg/models"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service interface {
 	GetCard(ctx context.Context, id string) (models.Card, error)
 	GetEnrichedCard(ctx context.Context, id string) (models.EnrichedCard, error)
 	GetCards(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Card, error)
+	ExpandCards(ctx context.Context, cards []models.Card, expand Expand) ([]models.ExpandedCard, error)
+	GetVariants(ctx context.Context, baseID string) ([]models.Card, error)
 
-	CreateCard(ctx context.Context, card *models.Card) error
+	CreateCard(ctx context.Context, card *models.Card, opts names.Options) error
 
-	UpdateCard(ctx context.Context, card *models.Card) error
+	UpdateCard(ctx context.Context, card *models.Card, opts names.Options) error
 
-	DeleteCard(ctx context.Context, id string) (models.Card, error)
+	DeleteCard(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error)
+}
+
+// Indexer is told about every card change so indexes derived from the
+// catalog, such as search, stay in sync.
+type Indexer interface {
+	IndexCard(ctx context.Context, card models.Card) error
+	RemoveCard(ctx context.Context, id string) error
 }
 
 type service struct {
@@ -31,23 +43,30 @@ type service struct {
 	groupRepo      groups.Repository
 	idolRepo       idols.Repository
 	collectionRepo collections.Repository
-	caser          cases.Caser
+	indexer        Indexer
+	deleter        deletion.Deleter
 }
 
 var _ Service = &service{}
 
-func NewService(repo Repository, groupRepo groups.Repository, idolRepo idols.Repository, collectionRepo collections.Repository) *service {
+func NewService(repo Repository, groupRepo groups.Repository, idolRepo idols.Repository, collectionRepo collections.Repository, indexer Indexer, deleter deletion.Deleter) *service {
 	return &service{
 		repo:           repo,
 		groupRepo:      groupRepo,
 		idolRepo:       idolRepo,
 		collectionRepo: collectionRepo,
-		caser:          cases.Title(language.English),
+		indexer:        indexer,
+		deleter:        deleter,
 	}
 }
 
 func (s *service) GetCard(ctx context.Context, id string) (models.Card, error) {
-	return s.repo.FindOne(ctx, id)
+	card, err := s.repo.FindOne(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Card{}, ErrNotFound
+	}
+
+	return card, err
 }
 
 func (s *service) GetEnrichedCard(ctx context.Context, id string) (models.EnrichedCard, error) {
@@ -56,9 +75,14 @@ func (s *service) GetEnrichedCard(ctx context.Context, id string) (models.Enrich
 		return models.EnrichedCard{}, err
 	}
 
-	group, err := s.groupRepo.FindOne(ctx, card.GroupID)
-	if err != nil {
-		return models.EnrichedCard{}, err
+	// Cards without a group or collection, or still pointing at a deleted
+	// one, are returned with that relation left empty.
+	var group models.Group
+	if card.GroupID != "" {
+		group, err = s.groupRepo.FindOne(ctx, card.GroupID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return models.EnrichedCard{}, err
+		}
 	}
 
 	idols, err := s.idolRepo.Find(ctx, card.IdolIDs)
@@ -66,9 +90,12 @@ func (s *service) GetEnrichedCard(ctx context.Context, id string) (models.Enrich
 		return models.EnrichedCard{}, err
 	}
 
-	collection, err := s.collectionRepo.FindOne(ctx, card.CollectionID)
-	if err != nil {
-		return models.EnrichedCard{}, err
+	var collection models.Collection
+	if card.CollectionID != "" {
+		collection, err = s.collectionRepo.FindOne(ctx, card.CollectionID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return models.EnrichedCard{}, err
+		}
 	}
 
 	fmtype := models.ShortTypesMap[card.Type]
@@ -79,6 +106,7 @@ func (s *service) GetEnrichedCard(ctx context.Context, id string) (models.Enrich
 	return models.EnrichedCard{
 		ID:         card.ID,
 		Name:       card.Name,
+		Names:      card.Names,
 		ShortName:  card.ShortName,
 		Type:       card.Type,
 		FmtType:    fmtype,
@@ -93,20 +121,202 @@ func (s *service) GetCards(ctx context.Context, filter map[string][]any, paginat
 	return s.repo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"type": 1}))
 }
 
-func (s *service) CreateCard(ctx context.Context, card *models.Card) error {
+func (s *service) GetVariants(ctx context.Context, baseID string) ([]models.Card, error) {
+	return s.repo.Search(ctx, map[string][]any{"base_card_id": {baseID}})
+}
+
+func (s *service) CreateCard(ctx context.Context, card *models.Card, opts names.Options) error {
 	card.ID = uuid.NewString()
-	card.Name = s.caser.String(card.Name)
-	return s.repo.Upsert(ctx, card.ID, *card)
+	card.Name = opts.Name(card.Name)
+	card.Names = opts.Localized(card.Names)
+
+	if err := s.resolveVariant(ctx, card); err != nil {
+		return err
+	}
+
+	if err := s.validateReferences(ctx, *card); err != nil {
+		return err
+	}
+
+	if err := s.repo.Upsert(ctx, card.ID, *card); err != nil {
+		return err
+	}
+
+	return s.indexer.IndexCard(ctx, *card)
+}
+
+func (s *service) UpdateCard(ctx context.Context, card *models.Card, opts names.Options) error {
+	stored, err := s.GetCard(ctx, card.ID)
+	if err != nil {
+		return err
+	}
+
+	// Credit for submissions is kept across updates that leave it out.
+	card.Contributors = mergeContributors(stored.Contributors, card.Contributors)
+
+	card.Name = opts.Name(card.Name)
+	card.Names = opts.Localized(card.Names)
+
+	if err := s.resolveVariant(ctx, card); err != nil {
+		return err
+	}
+
+	if err := s.validateReferences(ctx, *card); err != nil {
+		return err
+	}
+
+	if card.IsVariant() {
+		variants, err := s.repo.CountDocuments(ctx, map[string][]any{"base_card_id": {card.ID}})
+		if err != nil {
+			return err
+		}
+
+		if variants > 0 {
+			return ErrInvalidVariant
+		}
+	}
+
+	if err := s.repo.Replace(ctx, *card); err != nil {
+		return err
+	}
+
+	updated, err := s.repo.FindOne(ctx, card.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.indexer.IndexCard(ctx, updated)
+}
+
+func (s *service) DeleteCard(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error) {
+	report, err := s.deleter.DeleteCard(ctx, id, opts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return report, ErrNotFound
+	}
+
+	return report, err
+}
+
+// resolveVariant checks the base card of a variant and copies the catalog
+// fields the variant leaves empty from it. Variants must point at a base
+// card, never at another variant.
+func (s *service) resolveVariant(ctx context.Context, card *models.Card) error {
+	if !card.IsVariant() {
+		if card.Variant != nil {
+			return ErrInvalidVariant
+		}
+		return nil
+	}
+
+	if card.BaseCardID == card.ID || card.Variant == nil || !validVariantKind(card.Variant.Kind) {
+		return ErrInvalidVariant
+	}
+
+	base, err := s.repo.FindOne(ctx, card.BaseCardID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrBaseNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if base.IsVariant() {
+		return ErrInvalidVariant
+	}
+
+	if card.Name == "" {
+		card.Name = base.Name
+	}
+	if card.Names == (models.LocalizedNames{}) {
+		card.Names = base.Names
+	}
+	if card.ShortName == "" {
+		card.ShortName = base.ShortName
+	}
+	if card.Type == "" {
+		card.Type = base.Type
+	}
+	if card.GroupID == "" {
+		card.GroupID = base.GroupID
+	}
+	if card.CollectionID == "" {
+		card.CollectionID = base.CollectionID
+	}
+	if len(card.IdolIDs) == 0 {
+		card.IdolIDs = base.IdolIDs
+	}
+
+	return nil
+}
+
+// validateReferences checks that the group, collection and idols the card
+// points at exist.
+func (s *service) validateReferences(ctx context.Context, card models.Card) error {
+	if card.GroupID != "" {
+		if _, err := s.groupRepo.FindOne(ctx, card.GroupID); err != nil {
+			return missingReference(err, "group", card.GroupID)
+		}
+	}
+
+	if card.CollectionID != "" {
+		if _, err := s.collectionRepo.FindOne(ctx, card.CollectionID); err != nil {
+			return missingReference(err, "collection", card.CollectionID)
+		}
+	}
+
+	if len(card.IdolIDs) == 0 {
+		return nil
+	}
+
+	idols, err := s.idolRepo.Find(ctx, card.IdolIDs)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]struct{}, len(idols))
+	for _, idol := range idols {
+		found[idol.ID] = struct{}{}
+	}
+
+	for _, id := range card.IdolIDs {
+		if _, ok := found[id]; !ok {
+			return fmt.Errorf("%w: idol %s does not exist", ErrInvalidReference, id)
+		}
+	}
+
+	return nil
+}
+
+func missingReference(err error, kind, id string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: %s %s does not exist", ErrInvalidReference, kind, id)
+	}
+
+	return err
 }
 
-func (s *service) UpdateCard(ctx context.Context, card *models.Card) error {
-	if card.Name != "" {
-		card.Name = s.caser.String(card.Name)
+func mergeContributors(stored, given []string) []string {
+	seen := make(map[string]struct{}, len(stored)+len(given))
+	merged := make([]string, 0, len(stored)+len(given))
+
+	for _, id := range append(append([]string{}, stored...), given...) {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		merged = append(merged, id)
 	}
 
-	return s.repo.Upsert(ctx, card.ID, *card)
+	return merged
 }
 
-func (s *service) DeleteCard(ctx context.Context, id string) (models.Card, error) {
-	return s.repo.Delete(ctx, id)
+func validVariantKind(kind models.VariantKind) bool {
+	for _, k := range models.VariantKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
 }
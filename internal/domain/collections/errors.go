@@ -0,0 +1,9 @@
+package collections
+
+import "errors"
+
+var (
+	ErrNotFound = errors.New("collection not found")
+	// ErrInvalidReference is wrapped with the group that does not exist.
+	ErrInvalidReference = errors.New("invalid reference")
+)
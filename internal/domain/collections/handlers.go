@@ -2,49 +2,110 @@ package collections
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/filters"
 	"github.com/seosoojin/dalkom/internal/domain/handlers"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/internal/gateways/middlewares"
 	"github.com/seosoojin/dalkom/pkg/models"
 )
 
 type Handler interface {
 	GetCollections(w http.ResponseWriter, r *http.Request)
+	GetCollection(w http.ResponseWriter, r *http.Request)
 	CreateCollection(w http.ResponseWriter, r *http.Request)
+	UpdateCollection(w http.ResponseWriter, r *http.Request)
+	PatchCollection(w http.ResponseWriter, r *http.Request)
+	DeleteCollection(w http.ResponseWriter, r *http.Request)
 	handlers.Http
 }
 
 type handler struct {
-	service Service
+	service        Service
+	authMiddleware middlewares.Authenticator
 }
 
 var _ Handler = &handler{}
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, authMiddleware middlewares.Authenticator) *handler {
 	return &handler{
-		service: service,
+		service:        service,
+		authMiddleware: authMiddleware,
 	}
 }
 
 func (h *handler) RegisterRoutes(r *chi.Mux) {
 	r.Get("/collections", h.GetCollections)
-	r.Post("/collections", h.CreateCollection)
+	r.Get("/collections/{id}", h.GetCollection)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionEditCatalog))
+		r.Post("/collections", h.CreateCollection)
+		r.Put("/collections/{id}", h.UpdateCollection)
+		r.Patch("/collections/{id}", h.PatchCollection)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionDeleteCatalog))
+		r.Delete("/collections/{id}", h.DeleteCollection)
+	})
+}
+
+// collectionFilters lists the collection fields clients may filter on.
+var collectionFilters = filters.Schema{
+	"name":     {Kind: filters.String},
+	"group_id": {Kind: filters.String, Ops: filters.ReferenceOps},
 }
 
 func (h *handler) GetCollections(w http.ResponseWriter, r *http.Request) {
-	Collections, err := h.service.GetCollections(r.Context())
+	page, err := pagination.NewPageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := collectionFilters.Parse(r.URL.Query(), "offset", "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collections, err := h.service.GetCollections(r.Context(), filter, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	render.JSON(w, r, Collections)
+	render.JSON(w, r, collections)
+}
+
+func (h *handler) GetCollection(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	collection, err := h.service.GetCollection(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, collection)
 }
 
 func (h *handler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	collection := new(models.Collection)
 
 	b, err := io.ReadAll(r.Body)
@@ -59,10 +120,88 @@ func (h *handler) CreateCollection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Create(r.Context(), collection); err != nil {
+	if err := h.service.Create(r.Context(), collection, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, collection)
+}
+
+func (h *handler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, new(models.Collection))
+}
+
+func (h *handler) PatchCollection(w http.ResponseWriter, r *http.Request) {
+	collection, err := h.service.GetCollection(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.update(w, r, &collection)
+}
+
+func (h *handler) update(w http.ResponseWriter, r *http.Request, collection *models.Collection) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	err = json.Unmarshal(b, collection)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collection.ID = chi.URLParam(r, "id")
+	if err := h.service.Update(r.Context(), collection, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
 	render.JSON(w, r, collection)
 }
+
+func (h *handler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	opts, err := deletion.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.Delete(r.Context(), id, opts)
+	switch {
+	case errors.Is(err, deletion.ErrReferenced):
+		render.Status(r, http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+var errorStatuses = map[error]int{
+	ErrNotFound:         http.StatusNotFound,
+	ErrInvalidReference: http.StatusBadRequest,
+}
+
+func httpStatus(err error) int {
+	for target, status := range errorStatuses {
+		if errors.Is(err, target) {
+			return status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
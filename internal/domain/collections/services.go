@@ -2,54 +2,117 @@ package collections
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/groups"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
 	"github.com/seosoojin/dalkom/pkg/models"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service interface {
-	GetCollections(context.Context) ([]models.Collection, error)
+	GetCollections(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Collection, error)
 
-	Create(ctx context.Context, collection *models.Collection) error
+	GetCollection(ctx context.Context, id string) (models.Collection, error)
 
-	Update(ctx context.Context, collection *models.Collection) error
+	Create(ctx context.Context, collection *models.Collection, opts names.Options) error
 
-	Delete(ctx context.Context, id string) (models.Collection, error)
+	Update(ctx context.Context, collection *models.Collection, opts names.Options) error
+
+	Delete(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error)
+}
+
+// Indexer is told about collection changes so the cards showing the
+// collection's names are reindexed.
+type Indexer interface {
+	IndexCollection(ctx context.Context, id string) error
 }
 
 type service struct {
-	repo  Repository
-	caser cases.Caser
+	repo      Repository
+	groupRepo groups.Repository
+	deleter   deletion.Deleter
+	indexer   Indexer
 }
 
 var _ Service = &service{}
 
-func NewService(repo Repository) *service {
+func NewService(repo Repository, groupRepo groups.Repository, deleter deletion.Deleter, indexer Indexer) *service {
 	return &service{
-		repo:  repo,
-		caser: cases.Title(language.English),
+		repo:      repo,
+		groupRepo: groupRepo,
+		deleter:   deleter,
+		indexer:   indexer,
 	}
 }
 
-func (s *service) GetCollections(ctx context.Context) ([]models.Collection, error) {
-	return s.repo.FindAll(ctx)
+func (s *service) GetCollections(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Collection, error) {
+	return s.repo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"name": 1}))
+}
+
+func (s *service) GetCollection(ctx context.Context, id string) (models.Collection, error) {
+	collection, err := s.repo.FindOne(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Collection{}, ErrNotFound
+	}
+
+	return collection, err
 }
 
-func (s *service) Create(ctx context.Context, collection *models.Collection) error {
+func (s *service) Create(ctx context.Context, collection *models.Collection, opts names.Options) error {
 	collection.ID = uuid.NewString()
-	collection.Name = s.caser.String(collection.Name)
+	collection.Name = opts.Name(collection.Name)
+	collection.Names = opts.Localized(collection.Names)
+
+	if err := s.validateGroup(ctx, collection.GroupID); err != nil {
+		return err
+	}
+
 	return s.repo.Upsert(ctx, collection.ID, *collection)
 }
 
-func (s *service) Update(ctx context.Context, collection *models.Collection) error {
-	if collection.Name != "" {
-		collection.Name = s.caser.String(collection.Name)
+func (s *service) Update(ctx context.Context, collection *models.Collection, opts names.Options) error {
+	if _, err := s.GetCollection(ctx, collection.ID); err != nil {
+		return err
 	}
-	return s.repo.Upsert(ctx, collection.ID, *collection)
+
+	collection.Name = opts.Name(collection.Name)
+	collection.Names = opts.Localized(collection.Names)
+
+	if err := s.validateGroup(ctx, collection.GroupID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Upsert(ctx, collection.ID, *collection); err != nil {
+		return err
+	}
+
+	return s.indexer.IndexCollection(ctx, collection.ID)
+}
+
+func (s *service) Delete(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error) {
+	report, err := s.deleter.DeleteCollection(ctx, id, opts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return report, ErrNotFound
+	}
+
+	return report, err
 }
 
-func (s *service) Delete(ctx context.Context, id string) (models.Collection, error) {
-	return s.repo.Delete(ctx, id)
+func (s *service) validateGroup(ctx context.Context, groupID string) error {
+	if groupID == "" {
+		return nil
+	}
+
+	_, err := s.groupRepo.FindOne(ctx, groupID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: group %s does not exist", ErrInvalidReference, groupID)
+	}
+
+	return err
 }
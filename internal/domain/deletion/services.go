@@ -0,0 +1,364 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+var ErrReferenced = errors.New("still referenced; delete with cascade to remove what depends on it")
+
+const (
+	resourceGroup      = "group"
+	resourceIdol       = "idol"
+	resourceCollection = "collection"
+	resourceCard       = "card"
+)
+
+// Options controls a delete. Without Cascade a document that anything still
+// refers to is kept; DryRun only reports what the delete would touch.
+type Options struct {
+	Cascade bool
+	DryRun  bool
+}
+
+func NewOptionsFromRequest(r *http.Request) (Options, error) {
+	var opts Options
+
+	for key, target := range map[string]*bool{"cascade": &opts.Cascade, "dry_run": &opts.DryRun} {
+		raw := r.URL.Query().Get(key)
+		if raw == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Options{}, err
+		}
+		*target = v
+	}
+
+	return opts, nil
+}
+
+// Deleter removes catalog documents according to the delete policy: a group
+// takes its idols, collections and cards with it, a collection its cards, an
+// idol the cards featuring only that idol, and a card its variants. Deleted
+// cards are taken out of every binder.
+type Deleter interface {
+	DeleteGroup(ctx context.Context, id string, opts Options) (models.DeletionReport, error)
+	DeleteIdol(ctx context.Context, id string, opts Options) (models.DeletionReport, error)
+	DeleteCollection(ctx context.Context, id string, opts Options) (models.DeletionReport, error)
+	DeleteCard(ctx context.Context, id string, opts Options) (models.DeletionReport, error)
+}
+
+// BinderRepository takes deleted cards out of binders, like binders.Detacher.
+type BinderRepository interface {
+	HoldingCards(ctx context.Context, cardIDs []string) ([]string, error)
+	DetachCards(ctx context.Context, cardIDs []string) (int64, error)
+}
+
+// Indexer is told about the cards a delete changes, like cards.Indexer.
+type Indexer interface {
+	IndexCard(ctx context.Context, card models.Card) error
+	RemoveCard(ctx context.Context, id string) error
+}
+
+type deleter struct {
+	cardRepo       wise.MongoRepository[models.Card]
+	idolRepo       wise.MongoRepository[models.Idol]
+	groupRepo      wise.MongoRepository[models.Group]
+	collectionRepo wise.MongoRepository[models.Collection]
+	binderRepo     BinderRepository
+	indexer        Indexer
+}
+
+var _ Deleter = &deleter{}
+
+func NewDeleter(cardRepo wise.MongoRepository[models.Card], idolRepo wise.MongoRepository[models.Idol], groupRepo wise.MongoRepository[models.Group], collectionRepo wise.MongoRepository[models.Collection], binderRepo BinderRepository, indexer Indexer) *deleter {
+	return &deleter{
+		cardRepo:       cardRepo,
+		idolRepo:       idolRepo,
+		groupRepo:      groupRepo,
+		collectionRepo: collectionRepo,
+		binderRepo:     binderRepo,
+		indexer:        indexer,
+	}
+}
+
+func (d *deleter) DeleteGroup(ctx context.Context, id string, opts Options) (models.DeletionReport, error) {
+	if _, err := d.groupRepo.FindOne(ctx, id); err != nil {
+		return models.DeletionReport{}, err
+	}
+
+	p := newPlan(resourceGroup, id)
+	p.groups = append(p.groups, id)
+
+	idols, err := d.idolRepo.Search(ctx, map[string][]any{"group_id": {id}})
+	if err != nil {
+		return models.DeletionReport{}, err
+	}
+	for _, idol := range idols {
+		p.idols = append(p.idols, idol.ID)
+	}
+
+	collections, err := d.collectionRepo.Search(ctx, map[string][]any{"group_id": {id}})
+	if err != nil {
+		return models.DeletionReport{}, err
+	}
+	for _, collection := range collections {
+		p.collections = append(p.collections, collection.ID)
+	}
+
+	cards, err := d.cardRepo.Search(ctx, map[string][]any{"group_id": {id}})
+	if err != nil {
+		return models.DeletionReport{}, err
+	}
+	for _, card := range cards {
+		p.deleteCard(card.ID)
+	}
+
+	return d.run(ctx, p, opts)
+}
+
+func (d *deleter) DeleteIdol(ctx context.Context, id string, opts Options) (models.DeletionReport, error) {
+	if _, err := d.idolRepo.FindOne(ctx, id); err != nil {
+		return models.DeletionReport{}, err
+	}
+
+	p := newPlan(resourceIdol, id)
+	p.idols = append(p.idols, id)
+
+	return d.run(ctx, p, opts)
+}
+
+func (d *deleter) DeleteCollection(ctx context.Context, id string, opts Options) (models.DeletionReport, error) {
+	if _, err := d.collectionRepo.FindOne(ctx, id); err != nil {
+		return models.DeletionReport{}, err
+	}
+
+	p := newPlan(resourceCollection, id)
+	p.collections = append(p.collections, id)
+
+	return d.run(ctx, p, opts)
+}
+
+func (d *deleter) DeleteCard(ctx context.Context, id string, opts Options) (models.DeletionReport, error) {
+	if _, err := d.cardRepo.FindOne(ctx, id); err != nil {
+		return models.DeletionReport{}, err
+	}
+
+	p := newPlan(resourceCard, id)
+	p.deleteCard(id)
+
+	return d.run(ctx, p, opts)
+}
+
+// plan is what a delete will remove. The deleted document itself is part of
+// it, so a delete is refused without cascade when the plan holds anything
+// else.
+type plan struct {
+	resource string
+	id       string
+
+	groups      []string
+	idols       []string
+	collections []string
+	cards       []string
+	deleted     map[string]struct{}
+	updated     map[string]models.Card
+	binders     []string
+}
+
+func newPlan(resource, id string) *plan {
+	return &plan{
+		resource: resource,
+		id:       id,
+		deleted:  map[string]struct{}{},
+		updated:  map[string]models.Card{},
+	}
+}
+
+func (p *plan) deleteCard(id string) {
+	if _, ok := p.deleted[id]; ok {
+		return
+	}
+
+	p.deleted[id] = struct{}{}
+	p.cards = append(p.cards, id)
+	delete(p.updated, id)
+}
+
+func (p *plan) dependents() int {
+	return len(p.groups) + len(p.idols) + len(p.collections) + len(p.cards) + len(p.updated) + len(p.binders) - 1
+}
+
+func (p *plan) report(opts Options) models.DeletionReport {
+	updated := make([]string, 0, len(p.updated))
+	for id := range p.updated {
+		updated = append(updated, id)
+	}
+
+	return models.DeletionReport{
+		Resource:     p.resource,
+		ID:           p.id,
+		Cascade:      opts.Cascade,
+		DryRun:       opts.DryRun,
+		Groups:       nonNil(p.groups),
+		Idols:        nonNil(p.idols),
+		Collections:  nonNil(p.collections),
+		Cards:        nonNil(p.cards),
+		UpdatedCards: updated,
+		Binders:      nonNil(p.binders),
+	}
+}
+
+// expand follows the references to what the plan deletes: the cards of its
+// collections and idols, the variants of its cards and the binders holding
+// them. Every lookup is skipped when there is nothing to look for, since an
+// empty filter would match the whole collection.
+func (d *deleter) expand(ctx context.Context, p *plan) error {
+	if len(p.collections) > 0 {
+		cards, err := d.cardRepo.Search(ctx, map[string][]any{"collection_id": anys(p.collections)})
+		if err != nil {
+			return err
+		}
+		for _, card := range cards {
+			p.deleteCard(card.ID)
+		}
+	}
+
+	if len(p.idols) > 0 {
+		cards, err := d.cardRepo.Search(ctx, map[string][]any{"idol_ids": anys(p.idols)})
+		if err != nil {
+			return err
+		}
+
+		gone := make(map[string]struct{}, len(p.idols))
+		for _, id := range p.idols {
+			gone[id] = struct{}{}
+		}
+
+		for _, card := range cards {
+			if _, ok := p.deleted[card.ID]; ok {
+				continue
+			}
+
+			remaining := make([]string, 0, len(card.IdolIDs))
+			for _, id := range card.IdolIDs {
+				if _, ok := gone[id]; !ok {
+					remaining = append(remaining, id)
+				}
+			}
+
+			if len(remaining) == 0 {
+				p.deleteCard(card.ID)
+				continue
+			}
+
+			card.IdolIDs = remaining
+			p.updated[card.ID] = card
+		}
+	}
+
+	if len(p.cards) > 0 {
+		variants, err := d.cardRepo.Search(ctx, map[string][]any{"base_card_id": anys(p.cards)})
+		if err != nil {
+			return err
+		}
+		for _, variant := range variants {
+			p.deleteCard(variant.ID)
+		}
+
+		binders, err := d.binderRepo.HoldingCards(ctx, p.cards)
+		if err != nil {
+			return err
+		}
+		p.binders = binders
+	}
+
+	return nil
+}
+
+func (d *deleter) run(ctx context.Context, p *plan, opts Options) (models.DeletionReport, error) {
+	if err := d.expand(ctx, p); err != nil {
+		return models.DeletionReport{}, err
+	}
+
+	report := p.report(opts)
+	if !opts.Cascade && p.dependents() > 0 {
+		return report, ErrReferenced
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if _, err := d.binderRepo.DetachCards(ctx, p.cards); err != nil {
+		return report, err
+	}
+
+	for _, card := range p.updated {
+		if err := d.cardRepo.Upsert(ctx, card.ID, card); err != nil {
+			return report, err
+		}
+
+		if err := d.indexer.IndexCard(ctx, card); err != nil {
+			return report, err
+		}
+	}
+
+	// Documents are deleted one by one: the repository drops filters on
+	// fields it does not index, so a DeleteMany on _id would empty the
+	// collection.
+	for _, id := range p.cards {
+		if _, err := d.cardRepo.Delete(ctx, id); err != nil {
+			return report, err
+		}
+
+		if err := d.indexer.RemoveCard(ctx, id); err != nil {
+			return report, err
+		}
+	}
+
+	for _, id := range p.idols {
+		if _, err := d.idolRepo.Delete(ctx, id); err != nil {
+			return report, err
+		}
+	}
+
+	for _, id := range p.collections {
+		if _, err := d.collectionRepo.Delete(ctx, id); err != nil {
+			return report, err
+		}
+	}
+
+	for _, id := range p.groups {
+		if _, err := d.groupRepo.Delete(ctx, id); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func anys(ids []string) []any {
+	out := make([]any, len(ids))
+	for i, id := range ids {
+		out[i] = id
+	}
+
+	return out
+}
+
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+
+	return ids
+}
@@ -0,0 +1,329 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// OrKey is the query parameter holding an OR group: field:expression pairs
+// separated by |, e.g. or=type:pob|name:prefix:Fe.
+const OrKey = "or"
+
+type Kind int
+
+const (
+	String Kind = iota
+	Bool
+	Int
+	Time
+)
+
+type Op string
+
+const (
+	OpEq       Op = "eq"
+	OpNe       Op = "ne"
+	OpNot      Op = "not"
+	OpIn       Op = "in"
+	OpNin      Op = "nin"
+	OpAll      Op = "all"
+	OpPrefix   Op = "prefix"
+	OpContains Op = "contains"
+	OpGt       Op = "gt"
+	OpGte      Op = "gte"
+	OpLt       Op = "lt"
+	OpLte      Op = "lte"
+)
+
+var kindOps = map[Kind][]Op{
+	String: {OpEq, OpNe, OpNot, OpIn, OpNin, OpPrefix, OpContains},
+	Bool:   {OpEq, OpNe, OpNot},
+	Int:    {OpEq, OpNe, OpNot, OpIn, OpNin, OpGt, OpGte, OpLt, OpLte},
+	Time:   {OpEq, OpNe, OpNot, OpGt, OpGte, OpLt, OpLte},
+}
+
+// ReferenceOps are the operators that make sense on id and enum fields.
+var ReferenceOps = []Op{OpEq, OpNe, OpNot, OpIn, OpNin}
+
+var listOps = map[Op]struct{}{
+	OpIn:  {},
+	OpNin: {},
+	OpAll: {},
+}
+
+// mongoOps maps operators to their query operator. Prefix and contains both
+// become $regex.
+var mongoOps = map[Op]string{
+	OpEq:       "$eq",
+	OpNe:       "$ne",
+	OpNot:      "$ne",
+	OpIn:       "$in",
+	OpNin:      "$nin",
+	OpAll:      "$all",
+	OpPrefix:   "$regex",
+	OpContains: "$regex",
+	OpGt:       "$gt",
+	OpGte:      "$gte",
+	OpLt:       "$lt",
+	OpLte:      "$lte",
+}
+
+// Field describes a filterable field. Many marks array fields, which always
+// accept all:. Enum restricts the accepted values and Ops the accepted
+// operators; by default every operator of the kind is allowed.
+type Field struct {
+	Kind Kind
+	Many bool
+	Enum []string
+	Ops  []Op
+}
+
+func (f Field) allows(op Op) bool {
+	if op == OpAll {
+		return f.Many
+	}
+
+	ops := f.Ops
+	if ops == nil {
+		ops = kindOps[f.Kind]
+	}
+
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Schema lists the fields of a resource that may be filtered on.
+type Schema map[string]Field
+
+type condition struct {
+	op     Op
+	values []any
+}
+
+// Parse reads query parameters of the form field=op:operand, e.g.
+// type=in:pob,event, idol_ids=all:a,b or name=prefix:Fe. A value without a
+// known operator is an equality match. Repeated parameters for a field are
+// combined, so gte and lte make a range and repeated equalities match any of
+// the values. Parameters in ignore, such as pagination, are skipped.
+//
+// The result is a repository search filter: plain values for equality, an
+// operator document otherwise, and OR groups under $and.
+func (s Schema) Parse(values url.Values, ignore ...string) (map[string][]any, error) {
+	skip := make(map[string]struct{}, len(ignore))
+	for _, key := range ignore {
+		skip[key] = struct{}{}
+	}
+
+	filter := map[string][]any{}
+	groups := bson.A{}
+
+	for key, raw := range values {
+		if _, ok := skip[key]; ok {
+			continue
+		}
+
+		if key == OrKey {
+			for _, v := range raw {
+				group, err := s.parseOr(v)
+				if err != nil {
+					return nil, err
+				}
+				groups = append(groups, group)
+			}
+			continue
+		}
+
+		field, ok := s[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, key)
+		}
+
+		conds := make([]condition, 0, len(raw))
+		for _, v := range raw {
+			cond, err := parseCondition(key, field, v)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+		}
+
+		expr, err := build(key, conds)
+		if err != nil {
+			return nil, err
+		}
+
+		filter[key] = expr
+	}
+
+	if len(groups) > 0 {
+		filter["$and"] = []any{groups}
+	}
+
+	return filter, nil
+}
+
+// parseOr reads one OR group such as type:pob|name:prefix:Fe.
+func (s Schema) parseOr(value string) (bson.M, error) {
+	clauses := bson.A{}
+
+	for _, clause := range strings.Split(value, "|") {
+		key, expr, ok := strings.Cut(clause, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: or clause %q must be field:expression", ErrInvalidFilter, clause)
+		}
+
+		field, ok := s[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, key)
+		}
+
+		cond, err := parseCondition(key, field, expr)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := build(key, []condition{cond})
+		if err != nil {
+			return nil, err
+		}
+
+		match := values[0]
+		if len(values) > 1 {
+			match = bson.M{"$in": values}
+		}
+
+		clauses = append(clauses, bson.M{key: match})
+	}
+
+	if len(clauses) < 2 {
+		return nil, fmt.Errorf("%w: or needs at least two clauses", ErrInvalidFilter)
+	}
+
+	return bson.M{"$or": clauses}, nil
+}
+
+func parseCondition(key string, field Field, value string) (condition, error) {
+	op := OpEq
+	operand := value
+
+	if prefix, rest, ok := strings.Cut(value, ":"); ok {
+		if _, known := mongoOps[Op(prefix)]; known {
+			op, operand = Op(prefix), rest
+		}
+	}
+
+	if !field.allows(op) {
+		return condition{}, fmt.Errorf("%w: %s does not support %s", ErrInvalidFilter, key, op)
+	}
+
+	operands := []string{operand}
+	if _, ok := listOps[op]; ok {
+		operands = strings.Split(operand, ",")
+	}
+
+	cond := condition{op: op, values: make([]any, 0, len(operands))}
+	for _, o := range operands {
+		v, err := field.convert(o)
+		if err != nil {
+			return condition{}, fmt.Errorf("%w: %s: %v", ErrInvalidFilter, key, err)
+		}
+
+		if op == OpPrefix {
+			v = "^" + regexp.QuoteMeta(o)
+		} else if op == OpContains {
+			v = regexp.QuoteMeta(o)
+		}
+
+		cond.values = append(cond.values, v)
+	}
+
+	return cond, nil
+}
+
+func (f Field) convert(value string) (any, error) {
+	if len(f.Enum) > 0 {
+		found := false
+		for _, e := range f.Enum {
+			if e == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%q is not one of %s", value, strings.Join(f.Enum, ", "))
+		}
+	}
+
+	switch f.Kind {
+	case Bool:
+		return strconv.ParseBool(value)
+	case Int:
+		return strconv.Atoi(value)
+	case Time:
+		return time.Parse(time.RFC3339, value)
+	default:
+		return value, nil
+	}
+}
+
+// build combines the conditions on a field. Plain equalities stay plain
+// values so the repository matches any of them; anything else becomes a
+// single operator document.
+func build(key string, conds []condition) ([]any, error) {
+	eqs := []any{}
+	doc := bson.M{}
+
+	for _, c := range conds {
+		if c.op == OpEq {
+			eqs = append(eqs, c.values...)
+			continue
+		}
+
+		name := mongoOps[c.op]
+		if _, ok := doc[name]; ok {
+			return nil, fmt.Errorf("%w: %s has conflicting %s conditions", ErrInvalidFilter, key, c.op)
+		}
+
+		switch {
+		case name == "$regex":
+			doc[name] = c.values[0]
+			doc["$options"] = "i"
+		case c.op == OpIn || c.op == OpNin || c.op == OpAll:
+			doc[name] = c.values
+		default:
+			doc[name] = c.values[0]
+		}
+	}
+
+	if len(doc) == 0 {
+		return eqs, nil
+	}
+
+	switch len(eqs) {
+	case 0:
+	case 1:
+		doc["$eq"] = eqs[0]
+	default:
+		if _, ok := doc["$in"]; ok {
+			return nil, fmt.Errorf("%w: %s has conflicting in conditions", ErrInvalidFilter, key)
+		}
+		doc["$in"] = eqs
+	}
+
+	return []any{doc}, nil
+}
@@ -0,0 +1,5 @@
+package groups
+
+import "errors"
+
+var ErrNotFound = errors.New("group not found")
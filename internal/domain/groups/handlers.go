@@ -2,40 +2,82 @@ package groups
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/filters"
 	"github.com/seosoojin/dalkom/internal/domain/handlers"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/internal/gateways/middlewares"
 	"github.com/seosoojin/dalkom/pkg/models"
 )
 
 type Handler interface {
 	GetGroups(w http.ResponseWriter, r *http.Request)
+	GetGroup(w http.ResponseWriter, r *http.Request)
 	CreateGroup(w http.ResponseWriter, r *http.Request)
+	UpdateGroup(w http.ResponseWriter, r *http.Request)
+	PatchGroup(w http.ResponseWriter, r *http.Request)
+	DeleteGroup(w http.ResponseWriter, r *http.Request)
 	handlers.Http
 }
 
 type handler struct {
-	service Service
+	service        Service
+	authMiddleware middlewares.Authenticator
 }
 
 var _ Handler = &handler{}
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, authMiddleware middlewares.Authenticator) *handler {
 	return &handler{
-		service: service,
+		service:        service,
+		authMiddleware: authMiddleware,
 	}
 }
 
 func (h *handler) RegisterRoutes(r *chi.Mux) {
 	r.Get("/groups", h.GetGroups)
-	r.Post("/groups", h.CreateGroup)
+	r.Get("/groups/{id}", h.GetGroup)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionEditCatalog))
+		r.Post("/groups", h.CreateGroup)
+		r.Put("/groups/{id}", h.UpdateGroup)
+		r.Patch("/groups/{id}", h.PatchGroup)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionDeleteCatalog))
+		r.Delete("/groups/{id}", h.DeleteGroup)
+	})
+}
+
+// groupFilters lists the group fields clients may filter on.
+var groupFilters = filters.Schema{
+	"name": {Kind: filters.String},
 }
 
 func (h *handler) GetGroups(w http.ResponseWriter, r *http.Request) {
-	groups, err := h.service.GetGroups(r.Context())
+	page, err := pagination.NewPageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := groupFilters.Parse(r.URL.Query(), "offset", "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.service.GetGroups(r.Context(), filter, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -44,7 +86,25 @@ func (h *handler) GetGroups(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, groups)
 }
 
+func (h *handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	group, err := h.service.GetGroup(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, group)
+}
+
 func (h *handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	group := new(models.Group)
 
 	b, err := io.ReadAll(r.Body)
@@ -59,10 +119,89 @@ func (h *handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Create(r.Context(), group); err != nil {
+	if err := h.service.Create(r.Context(), group, opts); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	render.JSON(w, r, group)
 }
+
+func (h *handler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, new(models.Group))
+}
+
+func (h *handler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.service.GetGroup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.update(w, r, &group)
+}
+
+// update decodes the body over group and saves it. PUT starts from an empty
+// group, PATCH from the stored one so omitted fields are kept.
+func (h *handler) update(w http.ResponseWriter, r *http.Request, group *models.Group) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.Unmarshal(b, group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group.ID = chi.URLParam(r, "id")
+	if err := h.service.Update(r.Context(), group, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, group)
+}
+
+func (h *handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	opts, err := deletion.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.Delete(r.Context(), id, opts)
+	switch {
+	case errors.Is(err, deletion.ErrReferenced):
+		render.Status(r, http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+var errorStatuses = map[error]int{
+	ErrNotFound: http.StatusNotFound,
+}
+
+func httpStatus(err error) int {
+	for target, status := range errorStatuses {
+		if errors.Is(err, target) {
+			return status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
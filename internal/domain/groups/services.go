@@ -2,54 +2,90 @@ package groups
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
 	"github.com/seosoojin/dalkom/pkg/models"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service interface {
-	GetGroups(ctx context.Context) ([]models.Group, error)
+	GetGroups(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Group, error)
 
-	Create(ctx context.Context, group *models.Group) error
+	GetGroup(ctx context.Context, id string) (models.Group, error)
 
-	Update(ctx context.Context, group *models.Group) error
+	Create(ctx context.Context, group *models.Group, opts names.Options) error
 
-	Delete(ctx context.Context, id string) (models.Group, error)
+	Update(ctx context.Context, group *models.Group, opts names.Options) error
+
+	Delete(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error)
+}
+
+// Indexer is told about group changes so the cards showing the group's
+// names are reindexed.
+type Indexer interface {
+	IndexGroup(ctx context.Context, id string) error
 }
 
 type service struct {
-	repo  Repository
-	caser cases.Caser
+	repo    Repository
+	deleter deletion.Deleter
+	indexer Indexer
 }
 
 var _ Service = &service{}
 
-func NewService(repo Repository) *service {
+func NewService(repo Repository, deleter deletion.Deleter, indexer Indexer) *service {
 	return &service{
-		repo:  repo,
-		caser: cases.Title(language.English),
+		repo:    repo,
+		deleter: deleter,
+		indexer: indexer,
 	}
 }
 
-func (s *service) GetGroups(ctx context.Context) ([]models.Group, error) {
-	return s.repo.FindAll(ctx)
+func (s *service) GetGroups(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Group, error) {
+	return s.repo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"name": 1}))
+}
+
+func (s *service) GetGroup(ctx context.Context, id string) (models.Group, error) {
+	group, err := s.repo.FindOne(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Group{}, ErrNotFound
+	}
+
+	return group, err
 }
 
-func (s *service) Create(ctx context.Context, group *models.Group) error {
+func (s *service) Create(ctx context.Context, group *models.Group, opts names.Options) error {
 	group.ID = uuid.NewString()
-	group.Name = s.caser.String(group.Name)
-	return s.repo.Upsert(ctx, group.ID, *group)
+	group.Name = opts.Name(group.Name)
+	group.Names = opts.Localized(group.Names)
+	if err := s.repo.Upsert(ctx, group.ID, *group); err != nil {
+		return err
+	}
+
+	return s.indexer.IndexGroup(ctx, group.ID)
 }
 
-func (s *service) Update(ctx context.Context, group *models.Group) error {
-	if group.Name != "" {
-		group.Name = s.caser.String(group.Name)
+func (s *service) Update(ctx context.Context, group *models.Group, opts names.Options) error {
+	if _, err := s.GetGroup(ctx, group.ID); err != nil {
+		return err
 	}
+
+	group.Name = opts.Name(group.Name)
+	group.Names = opts.Localized(group.Names)
 	return s.repo.Upsert(ctx, group.ID, *group)
 }
 
-func (s *service) Delete(ctx context.Context, id string) (models.Group, error) {
-	return s.repo.Delete(ctx, id)
+func (s *service) Delete(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error) {
+	report, err := s.deleter.DeleteGroup(ctx, id, opts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return report, ErrNotFound
+	}
+
+	return report, err
 }
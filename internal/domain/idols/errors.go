@@ -0,0 +1,9 @@
+package idols
+
+import "errors"
+
+var (
+	ErrNotFound = errors.New("idol not found")
+	// ErrInvalidReference is wrapped with the group that does not exist.
+	ErrInvalidReference = errors.New("invalid reference")
+)
@@ -2,40 +2,84 @@ package idols
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/filters"
 	"github.com/seosoojin/dalkom/internal/domain/handlers"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/internal/gateways/middlewares"
 	"github.com/seosoojin/dalkom/pkg/models"
 )
 
 type Handler interface {
 	GetIdols(w http.ResponseWriter, r *http.Request)
+	GetIdol(w http.ResponseWriter, r *http.Request)
 	CreateIdol(w http.ResponseWriter, r *http.Request)
+	UpdateIdol(w http.ResponseWriter, r *http.Request)
+	PatchIdol(w http.ResponseWriter, r *http.Request)
+	DeleteIdol(w http.ResponseWriter, r *http.Request)
 	handlers.Http
 }
 
 type handler struct {
-	service Service
+	service        Service
+	authMiddleware middlewares.Authenticator
 }
 
 var _ Handler = &handler{}
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, authMiddleware middlewares.Authenticator) *handler {
 	return &handler{
-		service: service,
+		service:        service,
+		authMiddleware: authMiddleware,
 	}
 }
 
 func (h *handler) RegisterRoutes(r *chi.Mux) {
 	r.Get("/idols", h.GetIdols)
-	r.Post("/idols", h.CreateIdol)
+	r.Get("/idols/{id}", h.GetIdol)
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionEditCatalog))
+		r.Post("/idols", h.CreateIdol)
+		r.Put("/idols/{id}", h.UpdateIdol)
+		r.Patch("/idols/{id}", h.PatchIdol)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionDeleteCatalog))
+		r.Delete("/idols/{id}", h.DeleteIdol)
+	})
+}
+
+// idolFilters lists the idol fields clients may filter on.
+var idolFilters = filters.Schema{
+	"name":       {Kind: filters.String},
+	"stage_name": {Kind: filters.String},
+	"group_id":   {Kind: filters.String, Ops: filters.ReferenceOps},
 }
 
 func (h *handler) GetIdols(w http.ResponseWriter, r *http.Request) {
-	idols, err := h.service.GetIdols(r.Context())
+	page, err := pagination.NewPageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := idolFilters.Parse(r.URL.Query(), "offset", "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idols, err := h.service.GetIdols(r.Context(), filter, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -44,7 +88,25 @@ func (h *handler) GetIdols(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, idols)
 }
 
+func (h *handler) GetIdol(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	idol, err := h.service.GetIdol(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, idol)
+}
+
 func (h *handler) CreateIdol(w http.ResponseWriter, r *http.Request) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	idol := new(models.Idol)
 
 	b, err := io.ReadAll(r.Body)
@@ -59,10 +121,88 @@ func (h *handler) CreateIdol(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Create(r.Context(), idol); err != nil {
+	if err := h.service.Create(r.Context(), idol, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, idol)
+}
+
+func (h *handler) UpdateIdol(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, new(models.Idol))
+}
+
+func (h *handler) PatchIdol(w http.ResponseWriter, r *http.Request) {
+	idol, err := h.service.GetIdol(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	h.update(w, r, &idol)
+}
+
+func (h *handler) update(w http.ResponseWriter, r *http.Request, idol *models.Idol) {
+	opts, err := names.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	err = json.Unmarshal(b, idol)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idol.ID = chi.URLParam(r, "id")
+	if err := h.service.Update(r.Context(), idol, opts); err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
 	render.JSON(w, r, idol)
 }
+
+func (h *handler) DeleteIdol(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	opts, err := deletion.NewOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.Delete(r.Context(), id, opts)
+	switch {
+	case errors.Is(err, deletion.ErrReferenced):
+		render.Status(r, http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, report)
+}
+
+var errorStatuses = map[error]int{
+	ErrNotFound:         http.StatusNotFound,
+	ErrInvalidReference: http.StatusBadRequest,
+}
+
+func httpStatus(err error) int {
+	for target, status := range errorStatuses {
+		if errors.Is(err, target) {
+			return status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
@@ -2,60 +2,122 @@ package idols
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/internal/domain/deletion"
+	"github.com/seosoojin/dalkom/internal/domain/groups"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
 	"github.com/seosoojin/dalkom/pkg/models"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service interface {
-	GetIdols(context.Context) ([]models.Idol, error)
+	GetIdols(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Idol, error)
 
-	Create(ctx context.Context, idol *models.Idol) error
+	GetIdol(ctx context.Context, id string) (models.Idol, error)
 
-	Update(ctx context.Context, idol *models.Idol) error
+	Create(ctx context.Context, idol *models.Idol, opts names.Options) error
 
-	Delete(ctx context.Context, id string) (models.Idol, error)
+	Update(ctx context.Context, idol *models.Idol, opts names.Options) error
+
+	Delete(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error)
+}
+
+// Indexer is told about idol changes so the cards showing the idol's names
+// are reindexed.
+type Indexer interface {
+	IndexIdol(ctx context.Context, id string) error
 }
 
 type service struct {
-	repo  Repository
-	caser cases.Caser
+	repo      Repository
+	groupRepo groups.Repository
+	deleter   deletion.Deleter
+	indexer   Indexer
 }
 
 var _ Service = &service{}
 
-func NewService(repo Repository) *service {
+func NewService(repo Repository, groupRepo groups.Repository, deleter deletion.Deleter, indexer Indexer) *service {
 	return &service{
-		repo:  repo,
-		caser: cases.Title(language.English),
+		repo:      repo,
+		groupRepo: groupRepo,
+		deleter:   deleter,
+		indexer:   indexer,
 	}
 }
 
-func (s *service) GetIdols(ctx context.Context) ([]models.Idol, error) {
-	return s.repo.FindAll(ctx)
+func (s *service) GetIdols(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Idol, error) {
+	return s.repo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"stage_name": 1}))
+}
+
+func (s *service) GetIdol(ctx context.Context, id string) (models.Idol, error) {
+	idol, err := s.repo.FindOne(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Idol{}, ErrNotFound
+	}
+
+	return idol, err
 }
 
-func (s *service) Create(ctx context.Context, idol *models.Idol) error {
+func (s *service) Create(ctx context.Context, idol *models.Idol, opts names.Options) error {
 	idol.ID = uuid.NewString()
-	idol.Name = s.caser.String(idol.Name)
-	idol.StageName = s.caser.String(idol.StageName)
+	normalize(idol, opts)
+
+	if err := s.validateGroup(ctx, idol.GroupID); err != nil {
+		return err
+	}
+
 	return s.repo.Upsert(ctx, idol.ID, *idol)
 }
 
-func (s *service) Update(ctx context.Context, idol *models.Idol) error {
-	if idol.Name != "" {
-		idol.Name = s.caser.String(idol.Name)
+func (s *service) Update(ctx context.Context, idol *models.Idol, opts names.Options) error {
+	if _, err := s.GetIdol(ctx, idol.ID); err != nil {
+		return err
 	}
 
-	if idol.StageName != "" {
-		idol.StageName = s.caser.String(idol.StageName)
+	normalize(idol, opts)
+
+	if err := s.validateGroup(ctx, idol.GroupID); err != nil {
+		return err
 	}
 
-	return s.repo.Upsert(ctx, idol.ID, *idol)
+	if err := s.repo.Upsert(ctx, idol.ID, *idol); err != nil {
+		return err
+	}
+
+	return s.indexer.IndexIdol(ctx, idol.ID)
+}
+
+func (s *service) Delete(ctx context.Context, id string, opts deletion.Options) (models.DeletionReport, error) {
+	report, err := s.deleter.DeleteIdol(ctx, id, opts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return report, ErrNotFound
+	}
+
+	return report, err
 }
 
-func (s *service) Delete(ctx context.Context, id string) (models.Idol, error) {
-	return s.repo.Delete(ctx, id)
+func normalize(idol *models.Idol, opts names.Options) {
+	idol.Name = opts.Name(idol.Name)
+	idol.Names = opts.Localized(idol.Names)
+	idol.StageName = opts.Name(idol.StageName)
+	idol.StageNames = opts.Localized(idol.StageNames)
+}
+
+func (s *service) validateGroup(ctx context.Context, groupID string) error {
+	if groupID == "" {
+		return nil
+	}
+
+	_, err := s.groupRepo.FindOne(ctx, groupID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("%w: group %s does not exist", ErrInvalidReference, groupID)
+	}
+
+	return err
 }
@@ -0,0 +1,270 @@
+package integrity
+
+import (
+	"context"
+
+	"github.com/seosoojin/dalkom/internal/domain/binders"
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/collections"
+	"github.com/seosoojin/dalkom/internal/domain/groups"
+	"github.com/seosoojin/dalkom/internal/domain/idols"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+const (
+	resourceCard       = "card"
+	resourceIdol       = "idol"
+	resourceCollection = "collection"
+	resourceBinder     = "binder"
+)
+
+// Service finds references to deleted documents: cards pointing at missing
+// groups, collections, idols or base cards, idols and collections whose group
+// is gone, and binders holding deleted cards.
+type Service interface {
+	Check(ctx context.Context) (models.IntegrityReport, error)
+	// Repair clears the broken references it finds: cards, idols and
+	// collections lose the missing relation and binders drop the deleted
+	// cards.
+	Repair(ctx context.Context) (models.IntegrityReport, error)
+}
+
+type service struct {
+	cardRepo       cards.Repository
+	idolRepo       idols.Repository
+	groupRepo      groups.Repository
+	collectionRepo collections.Repository
+	binderRepo     binders.Repository
+	indexer        cards.Indexer
+}
+
+var _ Service = &service{}
+
+func NewService(cardRepo cards.Repository, idolRepo idols.Repository, groupRepo groups.Repository, collectionRepo collections.Repository, binderRepo binders.Repository, indexer cards.Indexer) *service {
+	return &service{
+		cardRepo:       cardRepo,
+		idolRepo:       idolRepo,
+		groupRepo:      groupRepo,
+		collectionRepo: collectionRepo,
+		binderRepo:     binderRepo,
+		indexer:        indexer,
+	}
+}
+
+// snapshot is every document the checks need, with the ids that exist.
+type snapshot struct {
+	cards       []models.Card
+	idols       []models.Idol
+	collections []models.Collection
+	binders     []models.Binder
+
+	cardIDs       map[string]struct{}
+	idolIDs       map[string]struct{}
+	groupIDs      map[string]struct{}
+	collectionIDs map[string]struct{}
+}
+
+func (s *service) load(ctx context.Context) (snapshot, error) {
+	var snap snapshot
+	var err error
+
+	if snap.cards, err = s.cardRepo.FindAll(ctx); err != nil {
+		return snap, err
+	}
+
+	if snap.idols, err = s.idolRepo.FindAll(ctx); err != nil {
+		return snap, err
+	}
+
+	if snap.collections, err = s.collectionRepo.FindAll(ctx); err != nil {
+		return snap, err
+	}
+
+	if snap.binders, err = s.binderRepo.FindAll(ctx); err != nil {
+		return snap, err
+	}
+
+	groups, err := s.groupRepo.FindAll(ctx)
+	if err != nil {
+		return snap, err
+	}
+
+	snap.cardIDs = ids(snap.cards, func(c models.Card) string { return c.ID })
+	snap.idolIDs = ids(snap.idols, func(i models.Idol) string { return i.ID })
+	snap.groupIDs = ids(groups, func(g models.Group) string { return g.ID })
+	snap.collectionIDs = ids(snap.collections, func(c models.Collection) string { return c.ID })
+
+	return snap, nil
+}
+
+func ids[T any](docs []T, id func(T) string) map[string]struct{} {
+	out := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		out[id(doc)] = struct{}{}
+	}
+
+	return out
+}
+
+// missing reports whether ref is set but not among ids.
+func missing(ids map[string]struct{}, ref string) bool {
+	if ref == "" {
+		return false
+	}
+
+	_, ok := ids[ref]
+	return !ok
+}
+
+func (s *service) Check(ctx context.Context) (models.IntegrityReport, error) {
+	snap, err := s.load(ctx)
+	if err != nil {
+		return models.IntegrityReport{}, err
+	}
+
+	return models.IntegrityReport{Issues: snap.issues()}, nil
+}
+
+func (snap snapshot) issues() []models.IntegrityIssue {
+	issues := []models.IntegrityIssue{}
+
+	check := func(ids map[string]struct{}, resource, id, field, ref string) {
+		if missing(ids, ref) {
+			issues = append(issues, models.IntegrityIssue{Resource: resource, ID: id, Field: field, Missing: ref})
+		}
+	}
+
+	for _, card := range snap.cards {
+		check(snap.groupIDs, resourceCard, card.ID, "group_id", card.GroupID)
+		check(snap.collectionIDs, resourceCard, card.ID, "collection_id", card.CollectionID)
+		check(snap.cardIDs, resourceCard, card.ID, "base_card_id", card.BaseCardID)
+		for _, idolID := range card.IdolIDs {
+			check(snap.idolIDs, resourceCard, card.ID, "idol_ids", idolID)
+		}
+	}
+
+	for _, idol := range snap.idols {
+		check(snap.groupIDs, resourceIdol, idol.ID, "group_id", idol.GroupID)
+	}
+
+	for _, collection := range snap.collections {
+		check(snap.groupIDs, resourceCollection, collection.ID, "group_id", collection.GroupID)
+	}
+
+	for _, binder := range snap.binders {
+		binder.MigrateLegacyCardIDs()
+		for _, entry := range binder.Entries {
+			check(snap.cardIDs, resourceBinder, binder.ID, "entries", entry.CardID)
+		}
+		for _, p := range binder.Placeholders {
+			check(snap.cardIDs, resourceBinder, binder.ID, "placeholders", p.CardID)
+		}
+	}
+
+	return issues
+}
+
+func (s *service) Repair(ctx context.Context) (models.IntegrityReport, error) {
+	if _, err := s.binderRepo.MigrateLegacyCardIDs(ctx); err != nil {
+		return models.IntegrityReport{}, err
+	}
+
+	snap, err := s.load(ctx)
+	if err != nil {
+		return models.IntegrityReport{}, err
+	}
+
+	report := models.IntegrityReport{Issues: snap.issues()}
+
+	for _, card := range snap.cards {
+		if !snap.repairCard(&card) {
+			continue
+		}
+
+		if err := s.cardRepo.Replace(ctx, card); err != nil {
+			return report, err
+		}
+
+		if err := s.indexer.IndexCard(ctx, card); err != nil {
+			return report, err
+		}
+		report.Repaired++
+	}
+
+	for _, idol := range snap.idols {
+		if !missing(snap.groupIDs, idol.GroupID) {
+			continue
+		}
+
+		idol.GroupID = ""
+		if err := s.idolRepo.Upsert(ctx, idol.ID, idol); err != nil {
+			return report, err
+		}
+		report.Repaired++
+	}
+
+	for _, collection := range snap.collections {
+		if !missing(snap.groupIDs, collection.GroupID) {
+			continue
+		}
+
+		collection.GroupID = ""
+		if err := s.collectionRepo.Upsert(ctx, collection.ID, collection); err != nil {
+			return report, err
+		}
+		report.Repaired++
+	}
+
+	deleted := []string{}
+	seen := map[string]struct{}{}
+	for _, issue := range report.Issues {
+		if _, ok := seen[issue.Missing]; ok || issue.Resource != resourceBinder {
+			continue
+		}
+		seen[issue.Missing] = struct{}{}
+		deleted = append(deleted, issue.Missing)
+	}
+
+	detached, err := s.binderRepo.DetachCards(ctx, deleted)
+	if err != nil {
+		return report, err
+	}
+	report.Repaired += detached
+
+	return report, nil
+}
+
+// repairCard drops the references of card to missing documents and reports
+// whether anything changed. A variant whose base card is gone becomes a card
+// of its own.
+func (snap snapshot) repairCard(card *models.Card) bool {
+	changed := false
+
+	if missing(snap.groupIDs, card.GroupID) {
+		card.GroupID = ""
+		changed = true
+	}
+
+	if missing(snap.collectionIDs, card.CollectionID) {
+		card.CollectionID = ""
+		changed = true
+	}
+
+	if missing(snap.cardIDs, card.BaseCardID) {
+		card.BaseCardID = ""
+		card.Variant = nil
+		changed = true
+	}
+
+	idolIDs := make([]string, 0, len(card.IdolIDs))
+	for _, id := range card.IdolIDs {
+		if missing(snap.idolIDs, id) {
+			changed = true
+			continue
+		}
+		idolIDs = append(idolIDs, id)
+	}
+	card.IdolIDs = idolIDs
+
+	return changed
+}
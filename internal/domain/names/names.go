@@ -0,0 +1,51 @@
+package names
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Options controls how names are normalized when entities are written. Names
+// are stored as given unless title casing is asked for, since stylised names
+// such as aespa or (G)I-DLE must keep their casing.
+type Options struct {
+	TitleCase bool
+}
+
+func NewOptionsFromRequest(r *http.Request) (Options, error) {
+	raw := r.URL.Query().Get("title_case")
+	if raw == "" {
+		return Options{}, nil
+	}
+
+	titleCase, err := strconv.ParseBool(raw)
+	if err != nil {
+		return Options{}, err
+	}
+
+	return Options{TitleCase: titleCase}, nil
+}
+
+// Name trims name and title cases it when asked.
+func (o Options) Name(name string) string {
+	name = strings.TrimSpace(name)
+	if !o.TitleCase {
+		return name
+	}
+
+	return cases.Title(language.English).String(name)
+}
+
+// Localized trims every form of a name. Only the English form is title cased.
+func (o Options) Localized(n models.LocalizedNames) models.LocalizedNames {
+	return models.LocalizedNames{
+		Ko: strings.TrimSpace(n.Ko),
+		En: o.Name(n.En),
+		Ja: strings.TrimSpace(n.Ja),
+	}
+}
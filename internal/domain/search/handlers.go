@@ -0,0 +1,66 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/seosoojin/dalkom/internal/domain/handlers"
+)
+
+type Handler interface {
+	Search(w http.ResponseWriter, r *http.Request)
+	handlers.Http
+}
+
+type handler struct {
+	service Service
+}
+
+var _ Handler = &handler{}
+
+func NewHandler(service Service) *handler {
+	return &handler{
+		service: service,
+	}
+}
+
+func (h *handler) RegisterRoutes(r *chi.Mux) {
+	r.Get("/search", h.Search)
+}
+
+func (h *handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	opts := Options{}
+	switch r.URL.Query().Get("group") {
+	case "":
+	case "base":
+		opts.GroupByBase = true
+	default:
+		http.Error(w, "group must be base", http.StatusBadRequest)
+		return
+	}
+
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		opts.Limit, err = strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := h.service.Search(r.Context(), query, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, results)
+}
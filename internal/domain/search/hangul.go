@@ -0,0 +1,136 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Precomposed Hangul syllables are laid out as
+// base + (initial*21 + medial)*28 + final.
+const (
+	syllableBase  = 0xAC00
+	syllableLast  = 0xD7A3
+	medialCount   = 21
+	finalCount    = 28
+	silentInitial = 11 // ㅇ
+)
+
+var (
+	initialJamo = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+	medialJamo  = []rune("ㅏㅐㅑㅒㅓㅔㅕㅖㅗㅘㅙㅚㅛㅜㅝㅞㅟㅠㅡㅢㅣ")
+	finalJamo   = []rune(" ㄱㄲㄳㄴㄵㄶㄷㄹㄺㄻㄼㄽㄾㄿㅀㅁㅂㅄㅅㅆㅇㅈㅊㅋㅌㅍㅎ")
+
+	// Revised romanization of each initial, medial and final.
+	initialRoman = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	medialRoman  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	finalRoman   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+
+	// linkedRoman is how a final is read when the next syllable starts with
+	// a vowel, as in 한국어 hangugeo. Finals missing here keep their sound.
+	linkedRoman = map[int]string{
+		1: "g", 2: "kk", 7: "d", 8: "r", 16: "m", 17: "b", 19: "s", 20: "ss",
+		22: "j", 23: "ch", 24: "k", 25: "t", 26: "p", 27: "",
+	}
+)
+
+func isSyllable(r rune) bool {
+	return r >= syllableBase && r <= syllableLast
+}
+
+func splitSyllable(r rune) (initial, medial, final int) {
+	i := int(r - syllableBase)
+	return i / (medialCount * finalCount), i % (medialCount * finalCount) / finalCount, i % finalCount
+}
+
+func hasHangul(word string) bool {
+	for _, r := range word {
+		if isSyllable(r) || unicode.Is(unicode.Hangul, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jamo spells Hangul syllables out letter by letter, so a word typed halfway
+// through a syllable, such as 카린 for 카리나, is still a prefix.
+func jamo(word string) string {
+	var b strings.Builder
+	for _, r := range word {
+		if !isSyllable(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		initial, medial, final := splitSyllable(r)
+		b.WriteRune(initialJamo[initial])
+		b.WriteRune(medialJamo[medial])
+		if final > 0 {
+			b.WriteRune(finalJamo[final])
+		}
+	}
+
+	return b.String()
+}
+
+// chosung returns the initial consonants of a word made of Hangul syllables,
+// e.g. ㅋㄹㄴ for 카리나, or "" for any other word.
+func chosung(word string) string {
+	var b strings.Builder
+	for _, r := range word {
+		if !isSyllable(r) {
+			return ""
+		}
+
+		initial, _, _ := splitSyllable(r)
+		b.WriteRune(initialJamo[initial])
+	}
+
+	return b.String()
+}
+
+// romanize transcribes Hangul syllables with the revised romanization,
+// carrying finals over to a following vowel and reading ㄹㄹ as ll. Other
+// sound changes are left to fuzzy matching.
+func romanize(word string) string {
+	runes := []rune(word)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if !isSyllable(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		initial, medial, final := splitSyllable(r)
+
+		prevFinal := 0
+		if i > 0 && isSyllable(runes[i-1]) {
+			_, _, prevFinal = splitSyllable(runes[i-1])
+		}
+
+		switch {
+		case initial == 5 && prevFinal == 8:
+			b.WriteString("l")
+		default:
+			b.WriteString(initialRoman[initial])
+		}
+		b.WriteString(medialRoman[medial])
+
+		if final == 0 {
+			continue
+		}
+
+		if i+1 < len(runes) && isSyllable(runes[i+1]) {
+			next, _, _ := splitSyllable(runes[i+1])
+			if linked, ok := linkedRoman[final]; ok && next == silentInitial {
+				b.WriteString(linked)
+				continue
+			}
+		}
+
+		b.WriteString(finalRoman[final])
+	}
+
+	return b.String()
+}
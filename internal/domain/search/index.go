@@ -0,0 +1,500 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/collections"
+	"github.com/seosoojin/dalkom/internal/domain/groups"
+	"github.com/seosoojin/dalkom/internal/domain/idols"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+// Field weights: how much a match on each field counts towards the score.
+const (
+	weightName       = 3
+	weightIdol       = 2.5
+	weightCollection = 2
+	weightShortName  = 2
+	weightType       = 1.5
+	weightGroup      = 1.5
+	weightVariant    = 1.5
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+
+	// rebuildInterval bounds how long changes made outside the server, such
+	// as doctor repairs, can take to show up.
+	rebuildInterval = 15 * time.Minute
+)
+
+// Options tune a search. GroupByBase folds variants into their base card.
+type Options struct {
+	Limit       int
+	GroupByBase bool
+}
+
+type Service interface {
+	Search(ctx context.Context, query string, opts Options) ([]models.SearchResult, error)
+}
+
+// Index is a search service that is kept current through card changes and
+// renames of the idols, groups and collections cards show.
+type Index interface {
+	Service
+	cards.Indexer
+	idols.Indexer
+	groups.Indexer
+	collections.Indexer
+}
+
+type posting struct {
+	cardID string
+	weight float64
+}
+
+type document struct {
+	card  models.Card
+	terms map[string]float64
+}
+
+// index is an in memory inverted index over the card catalog. It is built on
+// first use and kept current through the cards.Indexer hooks.
+type index struct {
+	cardRepo       cards.Repository
+	idolRepo       idols.Repository
+	groupRepo      groups.Repository
+	collectionRepo collections.Repository
+
+	// buildMu serialises builds with card updates, so a card changed while
+	// the catalog is being read is applied after the build instead of lost.
+	buildMu sync.Mutex
+
+	mu      sync.RWMutex
+	builtAt time.Time
+	docs    map[string]*document
+	terms   map[string][]posting
+}
+
+var _ Index = &index{}
+
+func NewIndex(cardRepo cards.Repository, idolRepo idols.Repository, groupRepo groups.Repository, collectionRepo collections.Repository) *index {
+	return &index{
+		cardRepo:       cardRepo,
+		idolRepo:       idolRepo,
+		groupRepo:      groupRepo,
+		collectionRepo: collectionRepo,
+	}
+}
+
+// Build indexes the whole catalog, replacing the current index.
+func (i *index) Build(ctx context.Context) error {
+	i.buildMu.Lock()
+	defer i.buildMu.Unlock()
+
+	return i.build(ctx)
+}
+
+func (i *index) build(ctx context.Context) error {
+	all, err := i.cardRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	names, err := i.names(ctx, all)
+	if err != nil {
+		return err
+	}
+
+	docs := make(map[string]*document, len(all))
+	terms := map[string][]posting{}
+	for _, card := range all {
+		doc := names.document(card)
+		docs[card.ID] = doc
+		for term, weight := range doc.terms {
+			terms[term] = append(terms[term], posting{cardID: card.ID, weight: weight})
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.docs = docs
+	i.terms = terms
+	i.builtAt = time.Now()
+
+	return nil
+}
+
+func (i *index) fresh() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return !i.builtAt.IsZero() && time.Since(i.builtAt) < rebuildInterval
+}
+
+func (i *index) ensureBuilt(ctx context.Context) error {
+	if i.fresh() {
+		return nil
+	}
+
+	i.buildMu.Lock()
+	defer i.buildMu.Unlock()
+
+	if i.fresh() {
+		return nil
+	}
+
+	return i.build(ctx)
+}
+
+func (i *index) IndexCard(ctx context.Context, card models.Card) error {
+	return i.indexCards(ctx, []models.Card{card})
+}
+
+func (i *index) IndexIdol(ctx context.Context, id string) error {
+	return i.reindex(ctx, map[string][]any{"idol_ids": {id}})
+}
+
+func (i *index) IndexGroup(ctx context.Context, id string) error {
+	return i.reindex(ctx, map[string][]any{"group_id": {id}})
+}
+
+func (i *index) IndexCollection(ctx context.Context, id string) error {
+	return i.reindex(ctx, map[string][]any{"collection_id": {id}})
+}
+
+// reindex refreshes the cards matching filter, whose documents hold the
+// names of the idols, groups and collections they refer to.
+func (i *index) reindex(ctx context.Context, filter map[string][]any) error {
+	if !i.built() {
+		return nil
+	}
+
+	affected, err := i.cardRepo.Search(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return i.indexCards(ctx, affected)
+}
+
+func (i *index) built() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return !i.builtAt.IsZero()
+}
+
+func (i *index) indexCards(ctx context.Context, all []models.Card) error {
+	if len(all) == 0 {
+		return nil
+	}
+
+	names, err := i.names(ctx, all)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]*document, 0, len(all))
+	for _, card := range all {
+		docs = append(docs, names.document(card))
+	}
+
+	i.buildMu.Lock()
+	defer i.buildMu.Unlock()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Cards changed before the first build are picked up by the build.
+	if i.builtAt.IsZero() {
+		return nil
+	}
+
+	for _, doc := range docs {
+		id := doc.card.ID
+		i.remove(id)
+
+		i.docs[id] = doc
+		for term, weight := range doc.terms {
+			i.terms[term] = append(i.terms[term], posting{cardID: id, weight: weight})
+		}
+	}
+
+	return nil
+}
+
+func (i *index) RemoveCard(_ context.Context, id string) error {
+	i.buildMu.Lock()
+	defer i.buildMu.Unlock()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.builtAt.IsZero() {
+		return nil
+	}
+
+	i.remove(id)
+	return nil
+}
+
+// remove drops a card from the index; the caller holds the write lock.
+func (i *index) remove(id string) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.terms {
+		postings := i.terms[term][:0]
+		for _, p := range i.terms[term] {
+			if p.cardID != id {
+				postings = append(postings, p)
+			}
+		}
+
+		if len(postings) == 0 {
+			delete(i.terms, term)
+			continue
+		}
+		i.terms[term] = postings
+	}
+
+	delete(i.docs, id)
+}
+
+type hit struct {
+	card     models.Card
+	matched  int
+	score    float64
+	variants []models.Card
+}
+
+// Search ranks cards by how many query words they match, then by how well.
+// Words may match exactly, by prefix or with a typo or two.
+func (i *index) Search(ctx context.Context, query string, opts Options) ([]models.SearchResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+
+	words := unique(tokenize(query))
+	if len(words) == 0 {
+		return []models.SearchResult{}, nil
+	}
+
+	if err := i.ensureBuilt(ctx); err != nil {
+		return nil, err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	hits := map[string]*hit{}
+	for _, word := range words {
+		forms := queryForms(word)
+
+		best := map[string]float64{}
+		for term, postings := range i.terms {
+			sim := 0.0
+			for _, q := range forms {
+				sim = max(sim, similarity(q, term))
+			}
+			if sim == 0 {
+				continue
+			}
+
+			for _, p := range postings {
+				best[p.cardID] = max(best[p.cardID], sim*p.weight)
+			}
+		}
+
+		for id, score := range best {
+			h, ok := hits[id]
+			if !ok {
+				h = &hit{card: i.docs[id].card}
+				hits[id] = h
+			}
+			h.matched++
+			h.score += score
+		}
+	}
+
+	if opts.GroupByBase {
+		hits = i.groupByBase(hits)
+	}
+
+	ranked := make([]*hit, 0, len(hits))
+	for _, h := range hits {
+		ranked = append(ranked, h)
+	}
+
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].matched != ranked[b].matched {
+			return ranked[a].matched > ranked[b].matched
+		}
+		if ranked[a].score != ranked[b].score {
+			return ranked[a].score > ranked[b].score
+		}
+		return ranked[a].card.Name < ranked[b].card.Name
+	})
+
+	results := make([]models.SearchResult, 0, min(limit, len(ranked)))
+	for _, h := range ranked[:min(limit, len(ranked))] {
+		results = append(results, models.SearchResult{
+			Card:     h.card,
+			Matched:  h.matched,
+			Score:    math.Round(h.score*100) / 100,
+			Variants: h.variants,
+		})
+	}
+
+	return results, nil
+}
+
+// groupByBase merges the hits on variants into a hit on their base card,
+// which ranks as its best matching member. The caller holds the read lock.
+func (i *index) groupByBase(hits map[string]*hit) map[string]*hit {
+	groups := make(map[string]*hit, len(hits))
+
+	for id, h := range hits {
+		baseID := h.card.BaseCardID
+		if baseID == "" {
+			baseID = id
+		}
+
+		g, ok := groups[baseID]
+		if !ok {
+			g = &hit{card: h.card}
+			if base, found := i.docs[baseID]; found {
+				g.card = base.card
+			}
+			groups[baseID] = g
+		}
+
+		if h.card.ID != g.card.ID {
+			g.variants = append(g.variants, h.card)
+		}
+		g.matched = max(g.matched, h.matched)
+		g.score = max(g.score, h.score)
+	}
+
+	for _, g := range groups {
+		sort.Slice(g.variants, func(a, b int) bool {
+			return g.variants[a].ID < g.variants[b].ID
+		})
+	}
+
+	return groups
+}
+
+// catalogNames resolves the names, in every language, of the idols, groups
+// and collections cards refer to.
+type catalogNames struct {
+	idols       map[string]models.Idol
+	groups      map[string][]string
+	collections map[string][]string
+}
+
+func (i *index) names(ctx context.Context, all []models.Card) (catalogNames, error) {
+	idolIDs, groupIDs, collectionIDs := []string{}, []string{}, []string{}
+	for _, card := range all {
+		idolIDs = append(idolIDs, card.IdolIDs...)
+		groupIDs = append(groupIDs, card.GroupID)
+		collectionIDs = append(collectionIDs, card.CollectionID)
+	}
+
+	names := catalogNames{
+		idols:       map[string]models.Idol{},
+		groups:      map[string][]string{},
+		collections: map[string][]string{},
+	}
+
+	idols, err := i.idolRepo.Find(ctx, unique(idolIDs))
+	if err != nil {
+		return names, err
+	}
+	for _, idol := range idols {
+		names.idols[idol.ID] = idol
+	}
+
+	groups, err := i.groupRepo.Find(ctx, unique(groupIDs))
+	if err != nil {
+		return names, err
+	}
+	for _, group := range groups {
+		names.groups[group.ID] = append([]string{group.Name}, group.Names.All()...)
+	}
+
+	collections, err := i.collectionRepo.Find(ctx, unique(collectionIDs))
+	if err != nil {
+		return names, err
+	}
+	for _, collection := range collections {
+		names.collections[collection.ID] = append([]string{collection.Name}, collection.Names.All()...)
+	}
+
+	return names, nil
+}
+
+func (n catalogNames) document(card models.Card) *document {
+	doc := &document{card: card, terms: map[string]float64{}}
+
+	add := func(text string, weight float64) {
+		for _, term := range indexTerms(text) {
+			doc.terms[term] = max(doc.terms[term], weight)
+		}
+	}
+
+	add(card.Name, weightName)
+	for _, name := range card.Names.All() {
+		add(name, weightName)
+	}
+	add(card.ShortName, weightShortName)
+	add(string(card.Type), weightType)
+	add(models.ShortTypesMap[card.Type], weightType)
+	if card.Variant != nil {
+		add(card.Variant.Label, weightVariant)
+	}
+	for _, name := range n.groups[card.GroupID] {
+		add(name, weightGroup)
+	}
+	for _, name := range n.collections[card.CollectionID] {
+		add(name, weightCollection)
+	}
+
+	for _, id := range card.IdolIDs {
+		idol := n.idols[id]
+		names := append(idol.StageNames.All(), idol.Names.All()...)
+		for _, name := range append(names, idol.StageName, idol.Name) {
+			add(name, weightIdol)
+		}
+	}
+
+	return doc
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if _, ok := seen[v]; ok || strings.TrimSpace(v) == "" {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+
+	return out
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize lowercases text and splits it into words of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// indexTerms tokenizes text for the index. Korean words are indexed spelled
+// out in jamo, romanized and by their initial consonants, so they can be found
+// from Hangul, Latin or chosung input.
+func indexTerms(text string) []string {
+	terms := []string{}
+	for _, word := range tokenize(text) {
+		if !hasHangul(word) {
+			terms = append(terms, word)
+			continue
+		}
+
+		terms = append(terms, jamo(word), romanize(word))
+		if initials := chosung(word); initials != "" {
+			terms = append(terms, initials)
+		}
+	}
+
+	return unique(terms)
+}
+
+// queryForms are the spellings of a query word to look up: Korean words are
+// matched in jamo and romanized.
+func queryForms(word string) [][]rune {
+	if !hasHangul(word) {
+		return [][]rune{[]rune(word)}
+	}
+
+	forms := [][]rune{}
+	for _, form := range unique([]string{jamo(word), romanize(word)}) {
+		forms = append(forms, []rune(form))
+	}
+
+	return forms
+}
+
+// maxTypos is how many edits a query word may be away from an indexed word.
+// Short words must match exactly or by prefix.
+func maxTypos(word []rune) int {
+	switch {
+	case len(word) < 4:
+		return 0
+	case len(word) < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance is the Levenshtein distance between a and b, giving up with
+// limit+1 once it exceeds limit.
+func distance(a, b []rune, limit int) int {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		best := curr[0]
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			best = min(best, curr[j])
+		}
+
+		if best > limit {
+			return limit + 1
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// similarity scores how well an indexed term matches a query word: 1 for an
+// exact match, less for prefixes and typos, 0 for no match.
+func similarity(query []rune, term string) float64 {
+	t := []rune(term)
+
+	if string(query) == term {
+		return 1
+	}
+
+	if len(query) >= 2 && len(t) > len(query) && string(t[:len(query)]) == string(query) {
+		return 0.8
+	}
+
+	limit := maxTypos(query)
+	if limit == 0 {
+		return 0
+	}
+
+	switch d := distance(query, t, limit); {
+	case d > limit:
+		return 0
+	case d == 1:
+		return 0.6
+	default:
+		return 0.4
+	}
+}
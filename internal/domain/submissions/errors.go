@@ -0,0 +1,13 @@
+package submissions
+
+import "errors"
+
+var (
+	ErrNotFound          = errors.New("submission not found")
+	ErrForbidden         = errors.New("forbidden")
+	ErrInvalidSubmission = errors.New("invalid submission")
+	ErrNoChanges         = errors.New("submission does not change the card")
+	ErrNotPending        = errors.New("submission is not pending review")
+	ErrNotRevisable      = errors.New("only pending submissions or ones with changes requested can be revised")
+	ErrConflict          = errors.New("submission was modified concurrently")
+)
@@ -0,0 +1,114 @@
+package submissions
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+// fixedFields are never part of a submission: the id is assigned by the
+// catalog and contributors are credited on approval.
+var fixedFields = map[string]struct{}{
+	"id":           {},
+	"contributors": {},
+}
+
+// fieldNames holds the json name of each models.Card field a submission may
+// change, by field index; other fields have no name. cardFields maps the
+// names back to their index.
+var (
+	fieldNames = func() []string {
+		t := reflect.TypeOf(models.Card{})
+		names := make([]string, t.NumField())
+
+		for i := range names {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if _, ok := fixedFields[name]; ok || name == "-" {
+				continue
+			}
+			names[i] = name
+		}
+
+		return names
+	}()
+
+	cardFields = func() map[string]int {
+		fields := make(map[string]int, len(fieldNames))
+		for i, name := range fieldNames {
+			if name != "" {
+				fields[name] = i
+			}
+		}
+
+		return fields
+	}()
+)
+
+// changedFields lists, in declaration order, the fields that differ between
+// two cards. Empty and missing lists are the same.
+func changedFields(current, proposed models.Card) []string {
+	cv, pv := reflect.ValueOf(current), reflect.ValueOf(proposed)
+
+	changed := []string{}
+	for i := 0; i < cv.NumField(); i++ {
+		name := fieldNames[i]
+		if name == "" {
+			continue
+		}
+
+		c, p := cv.Field(i), pv.Field(i)
+		if c.Kind() == reflect.Slice && c.Len() == 0 && p.Len() == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(c.Interface(), p.Interface()) {
+			changed = append(changed, name)
+		}
+	}
+
+	return changed
+}
+
+// applyFields copies fields from proposed onto card.
+func applyFields(card *models.Card, proposed models.Card, fields []string) {
+	cv, pv := reflect.ValueOf(card).Elem(), reflect.ValueOf(proposed)
+
+	for _, name := range fields {
+		if i, ok := cardFields[name]; ok {
+			cv.Field(i).Set(pv.Field(i))
+		}
+	}
+}
+
+// diff shows fields of current next to proposed. current is nil for new
+// cards.
+func diff(current *models.Card, proposed models.Card, fields []string) ([]models.FieldDiff, error) {
+	pv := reflect.ValueOf(proposed)
+
+	diffs := make([]models.FieldDiff, 0, len(fields))
+	for _, name := range fields {
+		i, ok := cardFields[name]
+		if !ok {
+			continue
+		}
+
+		d := models.FieldDiff{Field: name}
+
+		var err error
+		if d.Proposed, err = json.Marshal(pv.Field(i).Interface()); err != nil {
+			return nil, err
+		}
+
+		if current != nil {
+			if d.Current, err = json.Marshal(reflect.ValueOf(*current).Field(i).Interface()); err != nil {
+				return nil, err
+			}
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	return diffs, nil
+}
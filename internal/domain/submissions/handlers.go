@@ -0,0 +1,251 @@
+package submissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/filters"
+	"github.com/seosoojin/dalkom/internal/domain/handlers"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/internal/gateways/middlewares"
+	"github.com/seosoojin/dalkom/pkg/models"
+)
+
+type Handler interface {
+	Submit(w http.ResponseWriter, r *http.Request)
+	Revise(w http.ResponseWriter, r *http.Request)
+	GetByID(w http.ResponseWriter, r *http.Request)
+	GetByUserID(w http.ResponseWriter, r *http.Request)
+	GetQueue(w http.ResponseWriter, r *http.Request)
+	Diff(w http.ResponseWriter, r *http.Request)
+	Approve(w http.ResponseWriter, r *http.Request)
+	Reject(w http.ResponseWriter, r *http.Request)
+	RequestChanges(w http.ResponseWriter, r *http.Request)
+	handlers.Http
+}
+
+type handler struct {
+	service        Service
+	authMiddleware middlewares.Authenticator
+}
+
+var _ Handler = &handler{}
+
+func NewHandler(service Service, authMiddleware middlewares.Authenticator) *handler {
+	return &handler{
+		service:        service,
+		authMiddleware: authMiddleware,
+	}
+}
+
+func (h *handler) RegisterRoutes(r *chi.Mux) {
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate())
+		r.Get("/me/submissions", h.GetByUserID)
+		r.Post("/submissions", h.Submit)
+		r.Get("/submissions/{id}", h.GetByID)
+		r.Put("/submissions/{id}", h.Revise)
+		r.Get("/submissions/{id}/diff", h.Diff)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authMiddleware.Authenticate(), middlewares.RequirePermission(auth.PermissionModerateCatalog))
+		r.Get("/submissions", h.GetQueue)
+		r.Post("/submissions/{id}/approve", h.Approve)
+		r.Post("/submissions/{id}/reject", h.Reject)
+		r.Post("/submissions/{id}/request-changes", h.RequestChanges)
+	})
+}
+
+func (h *handler) Submit(w http.ResponseWriter, r *http.Request) {
+	var proposal models.SubmissionProposal
+	if err := json.NewDecoder(r.Body).Decode(&proposal); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	submission, err := h.service.Submit(r.Context(), user.ID, proposal)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, submission)
+}
+
+func (h *handler) Revise(w http.ResponseWriter, r *http.Request) {
+	var proposal models.SubmissionProposal
+	if err := json.NewDecoder(r.Body).Decode(&proposal); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	submission, err := h.service.Revise(r.Context(), user.ID, chi.URLParam(r, "id"), proposal)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, submission)
+}
+
+func (h *handler) GetByID(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	submission, err := h.service.GetSubmission(r.Context(), user, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, submission)
+}
+
+func (h *handler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.NewPageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	submissions, err := h.service.GetUserSubmissions(r.Context(), user.ID, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, submissions)
+}
+
+var submissionKinds = func() []string {
+	kinds := make([]string, 0, len(models.SubmissionKinds))
+	for _, k := range models.SubmissionKinds {
+		kinds = append(kinds, string(k))
+	}
+	return kinds
+}()
+
+var submissionStatuses = func() []string {
+	statuses := make([]string, 0, len(models.SubmissionStatuses))
+	for _, s := range models.SubmissionStatuses {
+		statuses = append(statuses, string(s))
+	}
+	return statuses
+}()
+
+// queueFilters lists the submission fields reviewers may filter on.
+var queueFilters = filters.Schema{
+	"status":  {Kind: filters.String, Enum: submissionStatuses, Ops: filters.ReferenceOps},
+	"kind":    {Kind: filters.String, Enum: submissionKinds, Ops: filters.ReferenceOps},
+	"card_id": {Kind: filters.String, Ops: filters.ReferenceOps},
+	"user_id": {Kind: filters.String, Ops: filters.ReferenceOps},
+}
+
+func (h *handler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.NewPageFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := queueFilters.Parse(r.URL.Query(), "offset", "limit")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !r.URL.Query().Has("status") {
+		filter["status"] = []any{models.SubmissionPending}
+	}
+
+	submissions, err := h.service.GetQueue(r.Context(), filter, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, submissions)
+}
+
+func (h *handler) Diff(w http.ResponseWriter, r *http.Request) {
+	user := auth.UserFromContext(r.Context())
+
+	diff, err := h.service.Diff(r.Context(), user, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, diff)
+}
+
+func (h *handler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Approve)
+}
+
+func (h *handler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Reject)
+}
+
+func (h *handler) RequestChanges(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.RequestChanges)
+}
+
+type decision func(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error)
+
+// review reads the optional review note and records the decision.
+func (h *handler) review(w http.ResponseWriter, r *http.Request, decide decision) {
+	var review models.SubmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := auth.UserFromContext(r.Context())
+
+	submission, err := decide(r.Context(), user.ID, chi.URLParam(r, "id"), review)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err))
+		return
+	}
+
+	render.JSON(w, r, submission)
+}
+
+var errorStatuses = map[error]int{
+	ErrNotFound:               http.StatusNotFound,
+	ErrForbidden:              http.StatusForbidden,
+	ErrInvalidSubmission:      http.StatusBadRequest,
+	ErrNoChanges:              http.StatusBadRequest,
+	ErrNotPending:             http.StatusConflict,
+	ErrNotRevisable:           http.StatusConflict,
+	ErrConflict:               http.StatusConflict,
+	cards.ErrNotFound:         http.StatusNotFound,
+	cards.ErrInvalidVariant:   http.StatusBadRequest,
+	cards.ErrBaseNotFound:     http.StatusBadRequest,
+	cards.ErrInvalidReference: http.StatusBadRequest,
+}
+
+func httpStatus(err error) int {
+	for target, status := range errorStatuses {
+		if errors.Is(err, target) {
+			return status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
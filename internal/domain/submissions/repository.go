@@ -0,0 +1,59 @@
+package submissions
+
+import (
+	"context"
+
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Repository interface {
+	wise.MongoRepository[models.Submission]
+
+	Replace(ctx context.Context, submission models.Submission, version int) error
+}
+
+type repository struct {
+	wise.MongoRepository[models.Submission]
+	collection *mongo.Collection
+}
+
+var _ Repository = &repository{}
+
+func NewRepository(col *mongo.Collection) (*repository, error) {
+	repo, err := wise.NewMongoSimpleRepository[models.Submission](col)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository{
+		MongoRepository: repo,
+		collection:      col,
+	}, nil
+}
+
+// Replace overwrites the submission as long as it is still at version,
+// failing with ErrConflict when someone else changed it first.
+func (r *repository) Replace(ctx context.Context, submission models.Submission, version int) error {
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"_id": submission.ID, "version": version}, submission)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	n, err := r.collection.CountDocuments(ctx, bson.M{"_id": submission.ID})
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return ErrConflict
+}
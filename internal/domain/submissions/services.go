@@ -0,0 +1,358 @@
+package submissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nextlevellabs/go-wise/wise"
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+	"github.com/seosoojin/dalkom/internal/domain/cards"
+	"github.com/seosoojin/dalkom/internal/domain/names"
+	"github.com/seosoojin/dalkom/internal/domain/pagination"
+	"github.com/seosoojin/dalkom/pkg/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Service is the moderation queue for catalog changes proposed by users.
+// Submissions start pending; reviewers approve them, which applies the
+// change and credits the contributor, reject them, or send them back to the
+// contributor for changes.
+type Service interface {
+	Submit(ctx context.Context, userID string, proposal models.SubmissionProposal) (models.Submission, error)
+	Revise(ctx context.Context, userID, id string, proposal models.SubmissionProposal) (models.Submission, error)
+
+	GetSubmission(ctx context.Context, user *models.User, id string) (models.Submission, error)
+	GetUserSubmissions(ctx context.Context, userID string, pagination pagination.Page) ([]models.Submission, error)
+	GetQueue(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Submission, error)
+	Diff(ctx context.Context, user *models.User, id string) (models.SubmissionDiff, error)
+
+	Approve(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error)
+	Reject(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error)
+	RequestChanges(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error)
+}
+
+type service struct {
+	repo        Repository
+	cardService cards.Service
+}
+
+var _ Service = &service{}
+
+func NewService(repo Repository, cardService cards.Service) *service {
+	return &service{
+		repo:        repo,
+		cardService: cardService,
+	}
+}
+
+func (s *service) Submit(ctx context.Context, userID string, proposal models.SubmissionProposal) (models.Submission, error) {
+	// Version 7 ids are time ordered, so sorting by id sorts by submission.
+	id, err := uuid.NewV7()
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	now := time.Now().UTC()
+	submission := models.Submission{
+		ID:        id.String(),
+		UserID:    userID,
+		Kind:      proposal.Kind,
+		CardID:    proposal.CardID,
+		Status:    models.SubmissionPending,
+		CreatedAt: now,
+	}
+
+	if err := s.propose(ctx, &submission, proposal); err != nil {
+		return models.Submission{}, err
+	}
+
+	if err := s.repo.Upsert(ctx, submission.ID, submission); err != nil {
+		return models.Submission{}, err
+	}
+
+	return submission, nil
+}
+
+// Revise replaces the proposed change of a submission that has not been
+// decided yet and puts it back in the queue. The kind and target card stay
+// as submitted.
+func (s *service) Revise(ctx context.Context, userID, id string, proposal models.SubmissionProposal) (models.Submission, error) {
+	submission, err := s.find(ctx, id)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	if submission.UserID != userID {
+		return models.Submission{}, ErrForbidden
+	}
+
+	if submission.Status != models.SubmissionPending && submission.Status != models.SubmissionChangesRequested {
+		return models.Submission{}, ErrNotRevisable
+	}
+
+	submission.Status = models.SubmissionPending
+	if err := s.propose(ctx, &submission, proposal); err != nil {
+		return models.Submission{}, err
+	}
+
+	if err := s.save(ctx, &submission); err != nil {
+		return models.Submission{}, err
+	}
+
+	return submission, nil
+}
+
+// propose reads the proposed card into submission and works out which fields
+// it changes.
+func (s *service) propose(ctx context.Context, submission *models.Submission, proposal models.SubmissionProposal) error {
+	if len(proposal.Card) == 0 {
+		return fmt.Errorf("%w: card is required", ErrInvalidSubmission)
+	}
+
+	var current, proposed models.Card
+
+	switch submission.Kind {
+	case models.SubmissionNewCard:
+		submission.CardID = ""
+	case models.SubmissionCorrection, models.SubmissionImage:
+		if submission.CardID == "" {
+			return fmt.Errorf("%w: card_id is required", ErrInvalidSubmission)
+		}
+
+		card, err := s.cardService.GetCard(ctx, submission.CardID)
+		if errors.Is(err, cards.ErrNotFound) {
+			return fmt.Errorf("%w: card %s does not exist", ErrInvalidSubmission, submission.CardID)
+		}
+		if err != nil {
+			return err
+		}
+
+		current = card
+		if proposed, err = clone(card); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: kind must be new_card, correction or image", ErrInvalidSubmission)
+	}
+
+	if err := json.Unmarshal(proposal.Card, &proposed); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSubmission, err)
+	}
+	proposed.ID = current.ID
+	proposed.Contributors = current.Contributors
+
+	fields := changedFields(current, proposed)
+	switch {
+	case len(fields) == 0:
+		return ErrNoChanges
+	case submission.Kind == models.SubmissionNewCard && proposed.Name == "":
+		return fmt.Errorf("%w: new cards need a name", ErrInvalidSubmission)
+	case submission.Kind == models.SubmissionImage && (len(fields) != 1 || fields[0] != "image_url"):
+		return fmt.Errorf("%w: image submissions only change image_url", ErrInvalidSubmission)
+	}
+
+	submission.Card = proposed
+	submission.Fields = fields
+	submission.Note = proposal.Note
+	submission.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// clone deep copies card, so decoding a proposal over the copy cannot reach
+// the lists and variant it shares with the original.
+func clone(card models.Card) (models.Card, error) {
+	var out models.Card
+
+	b, err := json.Marshal(card)
+	if err != nil {
+		return out, err
+	}
+
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func (s *service) find(ctx context.Context, id string) (models.Submission, error) {
+	submission, err := s.repo.FindOne(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Submission{}, ErrNotFound
+	}
+
+	return submission, err
+}
+
+// GetSubmission returns a submission to its contributor or to a reviewer.
+func (s *service) GetSubmission(ctx context.Context, user *models.User, id string) (models.Submission, error) {
+	submission, err := s.find(ctx, id)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	if submission.UserID != user.ID && !auth.Can(user, auth.PermissionModerateCatalog) {
+		return models.Submission{}, ErrNotFound
+	}
+
+	return submission, nil
+}
+
+func (s *service) GetUserSubmissions(ctx context.Context, userID string, pagination pagination.Page) ([]models.Submission, error) {
+	return s.repo.Search(ctx, map[string][]any{"user_id": {userID}}, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"_id": -1}))
+}
+
+// GetQueue lists submissions oldest first, so reviewers work through them in
+// the order they came in.
+func (s *service) GetQueue(ctx context.Context, filter map[string][]any, pagination pagination.Page) ([]models.Submission, error) {
+	return s.repo.Search(ctx, filter, wise.WithPage(pagination.Offset), wise.WithPageSize(pagination.Limit), wise.WithSort(map[string]int{"_id": 1}))
+}
+
+// Diff compares the fields a submission changes with the catalog as it is
+// now, which may have moved on since the submission was made.
+func (s *service) Diff(ctx context.Context, user *models.User, id string) (models.SubmissionDiff, error) {
+	submission, err := s.GetSubmission(ctx, user, id)
+	if err != nil {
+		return models.SubmissionDiff{}, err
+	}
+
+	var current *models.Card
+	if submission.Kind != models.SubmissionNewCard || submission.Status == models.SubmissionApproved {
+		card, err := s.cardService.GetCard(ctx, submission.CardID)
+		switch {
+		case err == nil:
+			current = &card
+		case !errors.Is(err, cards.ErrNotFound):
+			return models.SubmissionDiff{}, err
+		}
+	}
+
+	fields, err := diff(current, submission.Card, submission.Fields)
+	if err != nil {
+		return models.SubmissionDiff{}, err
+	}
+
+	return models.SubmissionDiff{Submission: submission, Fields: fields}, nil
+}
+
+// Approve claims the submission before touching the catalog, so concurrent
+// approvals apply it once. The claim is given back when the change cannot be
+// applied.
+func (s *service) Approve(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error) {
+	submission, err := s.pending(ctx, id)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	approved, err := s.decide(ctx, submission, models.SubmissionApproved, reviewerID, review)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	card, err := s.apply(ctx, submission)
+	if err != nil {
+		submission.Version = approved.Version
+		if rerr := s.save(ctx, &submission); rerr != nil {
+			return models.Submission{}, errors.Join(err, rerr)
+		}
+		return models.Submission{}, err
+	}
+
+	if approved.Kind != models.SubmissionNewCard {
+		return approved, nil
+	}
+
+	approved.CardID = card.ID
+	approved.Card = card
+	if err := s.save(ctx, &approved); err != nil {
+		return models.Submission{}, err
+	}
+
+	return approved, nil
+}
+
+// apply makes the change of a submission in the catalog and credits its
+// contributor.
+func (s *service) apply(ctx context.Context, submission models.Submission) (models.Card, error) {
+	if submission.Kind == models.SubmissionNewCard {
+		card := submission.Card
+		card.Contributors = []string{submission.UserID}
+
+		err := s.cardService.CreateCard(ctx, &card, names.Options{})
+		return card, err
+	}
+
+	card, err := s.cardService.GetCard(ctx, submission.CardID)
+	if err != nil {
+		return models.Card{}, err
+	}
+
+	applyFields(&card, submission.Card, submission.Fields)
+	card.Contributors = append(card.Contributors, submission.UserID)
+
+	err = s.cardService.UpdateCard(ctx, &card, names.Options{})
+	return card, err
+}
+
+func (s *service) Reject(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error) {
+	submission, err := s.pending(ctx, id)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	return s.decide(ctx, submission, models.SubmissionRejected, reviewerID, review)
+}
+
+func (s *service) RequestChanges(ctx context.Context, reviewerID, id string, review models.SubmissionReview) (models.Submission, error) {
+	submission, err := s.pending(ctx, id)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	return s.decide(ctx, submission, models.SubmissionChangesRequested, reviewerID, review)
+}
+
+func (s *service) pending(ctx context.Context, id string) (models.Submission, error) {
+	submission, err := s.find(ctx, id)
+	if err != nil {
+		return models.Submission{}, err
+	}
+
+	if submission.Status != models.SubmissionPending {
+		return models.Submission{}, ErrNotPending
+	}
+
+	return submission, nil
+}
+
+func (s *service) decide(ctx context.Context, submission models.Submission, status models.SubmissionStatus, reviewerID string, review models.SubmissionReview) (models.Submission, error) {
+	now := time.Now().UTC()
+
+	submission.Status = status
+	submission.ReviewerID = reviewerID
+	submission.ReviewNote = review.Note
+	submission.ReviewedAt = &now
+	submission.UpdatedAt = now
+
+	if err := s.save(ctx, &submission); err != nil {
+		return models.Submission{}, err
+	}
+
+	return submission, nil
+}
+
+// save writes a submission read earlier, failing with ErrConflict when it
+// changed in the meantime.
+func (s *service) save(ctx context.Context, submission *models.Submission) error {
+	version := submission.Version
+	submission.Version++
+
+	if err := s.repo.Replace(ctx, *submission, version); err != nil {
+		submission.Version = version
+		return err
+	}
+
+	return nil
+}
@@ -9,4 +9,5 @@ var (
 	ErrInvalidUsername    = errors.New("invalid username")
 	ErrInvalidEmail       = errors.New("invalid email")
 	ErrInvalidPassword    = errors.New("invalid password")
+	ErrInvalidRole        = errors.New("role must be admin, curator or member")
 )
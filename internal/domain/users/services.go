@@ -17,6 +17,9 @@ type Service interface {
 	GetByEmail(ctx context.Context, email string) (models.User, error)
 	Create(ctx context.Context, user *models.User) error
 	Update(ctx context.Context, user *models.User) error
+	// SetRole is only reachable from the command line, so no request can
+	// raise its own privileges.
+	SetRole(ctx context.Context, email string, role models.Role) (models.User, error)
 }
 
 type service struct {
@@ -109,6 +112,7 @@ func (s *service) Create(ctx context.Context, user *models.User) error {
 	}
 
 	user.ID = uuid.NewString()
+	user.Role = models.RoleMember
 	hashed, err := s.auth.HashPassword(user.Password)
 	if err != nil {
 		return err
@@ -147,10 +151,11 @@ func (s *service) Update(ctx context.Context, user *models.User) error {
 	user.Username = strings.ToLower(user.Username)
 	user.Email = strings.ToLower(user.Email)
 
-	_, err := s.GetByID(ctx, user.ID)
+	stored, err := s.GetByID(ctx, user.ID)
 	if err != nil {
 		return err
 	}
+	user.Role = stored.Role
 
 	if user.Password != "" {
 		hashed, err := s.auth.HashPassword(user.Password)
@@ -162,3 +167,21 @@ func (s *service) Update(ctx context.Context, user *models.User) error {
 
 	return s.repo.Upsert(ctx, user.ID, *user)
 }
+
+func (s *service) SetRole(ctx context.Context, email string, role models.Role) (models.User, error) {
+	if !role.Valid() {
+		return models.User{}, ErrInvalidRole
+	}
+
+	user, err := s.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.Role = role
+	if err := s.repo.Upsert(ctx, user.ID, user); err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
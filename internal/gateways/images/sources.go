@@ -0,0 +1,168 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image reference")
+	ErrNotFound    = errors.New("image not found")
+	ErrTooLarge    = errors.New("image is too large")
+)
+
+const (
+	// maxImageSize caps how much of an image file is read.
+	maxImageSize = 10 << 20
+	// maxImagePixels caps the decoded size: a small file can declare huge
+	// dimensions.
+	maxImagePixels = 4096 * 4096
+)
+
+//go:embed fixtures/*.png
+var fixtures embed.FS
+
+// Source loads the image a card image reference points to.
+type Source interface {
+	Load(ctx context.Context, ref string) (image.Image, error)
+}
+
+type httpSource struct {
+	client *http.Client
+}
+
+var _ Source = &httpSource{}
+
+func NewHTTPSource() *httpSource {
+	return &httpSource{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *httpSource) Load(ctx context.Context, ref string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %d", ErrNotFound, ref, res.StatusCode)
+	}
+
+	return decode(res.Body)
+}
+
+// fsSource reads images from a file system, such as a local directory or the
+// fixtures embedded in the binary.
+type fsSource struct {
+	fsys fs.FS
+}
+
+var _ Source = &fsSource{}
+
+// NewFileSource serves images from files below dir.
+func NewFileSource(dir string) *fsSource {
+	return &fsSource{fsys: os.DirFS(dir)}
+}
+
+// NewFixtureSource serves the fixture images bundled with the repository.
+func NewFixtureSource() *fsSource {
+	sub, _ := fs.Sub(fixtures, "fixtures")
+	return &fsSource{fsys: sub}
+}
+
+func (s *fsSource) Load(_ context.Context, ref string) (image.Image, error) {
+	name := path.Clean(strings.TrimPrefix(ref, "/"))
+	if !fs.ValidPath(name) {
+		return nil, ErrUnsupported
+	}
+
+	f, err := s.fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return decode(f)
+}
+
+// decode reads at most maxImageSize bytes and checks the dimensions the image
+// declares before decoding it.
+func decode(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxImageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width > maxImagePixels/config.Height {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// router picks a source by the scheme of the reference: http(s) URLs are
+// downloaded, fixture://name reads a bundled fixture and file://path or a
+// bare path reads from the local image directory.
+type router struct {
+	http     Source
+	files    Source
+	fixtures Source
+}
+
+var _ Source = &router{}
+
+func NewSource(dir string) *router {
+	return &router{
+		http:     NewHTTPSource(),
+		files:    NewFileSource(dir),
+		fixtures: NewFixtureSource(),
+	}
+}
+
+func (r *router) Load(ctx context.Context, ref string) (image.Image, error) {
+	switch {
+	case ref == "":
+		return nil, ErrNotFound
+	case strings.HasPrefix(ref, "http://"), strings.HasPrefix(ref, "https://"):
+		return r.http.Load(ctx, ref)
+	case strings.HasPrefix(ref, "fixture://"):
+		return r.fixtures.Load(ctx, strings.TrimPrefix(ref, "fixture://"))
+	case strings.HasPrefix(ref, "file://"):
+		return r.files.Load(ctx, strings.TrimPrefix(ref, "file://"))
+	case !strings.Contains(ref, "://"):
+		return r.files.Load(ctx, ref)
+	default:
+		return nil, ErrUnsupported
+	}
+}
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -10,8 +11,11 @@ import (
 
 type Authenticator interface {
 	Authenticate() func(next http.Handler) http.Handler
+	Identify() func(next http.Handler) http.Handler
 }
 
+var errUnauthorized = errors.New("unauthorized")
+
 type authenticator struct {
 	JWTService auth.JWTService
 }
@@ -27,28 +31,50 @@ func NewAuthenticator(jwtService auth.JWTService) *authenticator {
 func (a *authenticator) Authenticate() func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			bearer := r.Header.Get("Authorization")
-			if bearer == "" {
+			ctx, err := a.verify(r)
+			if err != nil {
 				http.Error(w, "unauthorized", http.StatusForbidden)
 				return
 			}
 
-			if !strings.HasPrefix(bearer, "Bearer ") {
-				http.Error(w, "unauthorized", http.StatusForbidden)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Identify attaches the user to the context when a valid token is sent but,
+// unlike Authenticate, lets anonymous requests through.
+func (a *authenticator) Identify() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			token := strings.TrimPrefix(bearer, "Bearer ")
-
-			jwt, err := a.JWTService.VerifyToken(token)
+			ctx, err := a.verify(r)
 			if err != nil {
 				http.Error(w, "unauthorized", http.StatusForbidden)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), auth.CTXJWTKEY, jwt.Claims)
-			r = r.WithContext(ctx)
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func (a *authenticator) verify(r *http.Request) (context.Context, error) {
+	bearer := r.Header.Get("Authorization")
+	if !strings.HasPrefix(bearer, "Bearer ") {
+		return nil, errUnauthorized
+	}
+
+	token := strings.TrimPrefix(bearer, "Bearer ")
+
+	jwt, err := a.JWTService.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(r.Context(), auth.CTXJWTKEY, jwt.Claims), nil
+}
@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/seosoojin/dalkom/internal/domain/auth"
+)
+
+// RequirePermission lets through only users whose role grants permission. It
+// reads the user Authenticate put in the context, so it must run after it.
+func RequirePermission(permission auth.Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.Can(auth.UserFromContext(r.Context()), permission) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
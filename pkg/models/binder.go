@@ -1,12 +1,260 @@
 package models
 
+import "time"
+
 type Binder struct {
-	ID          string    `json:"id" bson:"_id"`
-	ImageURL    string    `json:"image_url" bson:"image_url,omitempty"`
-	Name        string    `json:"name" bson:"name,omitempty"`
-	Description string    `json:"description" bson:"description,omitempty"`
-	UserID      string    `json:"user_id" bson:"user_id,omitempty" indexed:"true"`
-	IsFavorite  bool      `json:"is_favorite" bson:"is_favorite,omitempty" indexed:"true"`
-	Type        string    `json:"type" bson:"type,omitempty"`
-	CardIDs     *[]string `json:"card_ids" bson:"card_ids,omitempty"`
+	ID          string        `json:"id" bson:"_id"`
+	ImageURL    string        `json:"image_url" bson:"image_url,omitempty"`
+	Name        string        `json:"name" bson:"name,omitempty" indexed:"true"`
+	Description string        `json:"description" bson:"description,omitempty"`
+	UserID      string        `json:"user_id" bson:"user_id,omitempty" indexed:"true"`
+	IsFavorite  bool          `json:"is_favorite" bson:"is_favorite,omitempty" indexed:"true"`
+	Type        BinderType    `json:"type" bson:"type,omitempty" indexed:"true"`
+	Entries     []BinderEntry `json:"entries" bson:"entries,omitempty"`
+	Version     int           `json:"version" bson:"version,omitempty"`
+
+	Query CardQuery `json:"query,omitempty" bson:"query,omitempty"`
+
+	// CollectionID and Placeholders are set on binders generated from a
+	// collection template, reserving a pocket for every card of it.
+	CollectionID string              `json:"collection_id,omitempty" bson:"collection_id,omitempty"`
+	Placeholders []BinderPlaceholder `json:"placeholders,omitempty" bson:"placeholders,omitempty"`
+
+	Members []BinderMember `json:"members" bson:"members,omitempty" indexed:"true"`
+	// Role is the role of the requesting user, filled in on listings.
+	Role BinderRole `json:"role,omitempty" bson:"-"`
+
+	// Tags, FolderID and ShelfPosition organise the owner's shelf.
+	Tags          []string `json:"tags" bson:"tags,omitempty" indexed:"true"`
+	FolderID      string   `json:"folder_id" bson:"folder_id,omitempty" indexed:"true"`
+	ShelfPosition int      `json:"shelf_position" bson:"shelf_position,omitempty" indexed:"true"`
+
+	Visibility  BinderVisibility `json:"visibility" bson:"visibility,omitempty" indexed:"true"`
+	ShareTokens []ShareToken     `json:"share_tokens,omitempty" bson:"share_tokens,omitempty" indexed:"true"`
+
+	// CardIDs is the legacy storage of binder contents, kept so old
+	// documents can still be decoded and migrated into Entries.
+	CardIDs *[]string `json:"-" bson:"card_ids,omitempty"`
+}
+
+// CardQuery is a saved card catalog filter mapping a card field to the values
+// it may take. Binders with a query are smart: their contents are whatever
+// the catalog currently matches.
+type CardQuery map[string][]string
+
+type BinderRole string
+
+const (
+	BinderRoleOwner  BinderRole = "owner"
+	BinderRoleEditor BinderRole = "editor"
+	BinderRoleViewer BinderRole = "viewer"
+)
+
+type BinderMember struct {
+	UserID  string     `json:"user_id" bson:"user_id"`
+	Role    BinderRole `json:"role" bson:"role"`
+	AddedAt time.Time  `json:"added_at" bson:"added_at"`
+}
+
+type BinderVisibility string
+
+const (
+	BinderVisibilityPrivate  BinderVisibility = "private"
+	BinderVisibilityUnlisted BinderVisibility = "unlisted"
+	BinderVisibilityPublic   BinderVisibility = "public"
+)
+
+var BinderVisibilities = map[BinderVisibility]struct{}{
+	BinderVisibilityPrivate:  {},
+	BinderVisibilityUnlisted: {},
+	BinderVisibilityPublic:   {},
+}
+
+type ShareToken struct {
+	Token     string    `json:"token" bson:"token"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+type BinderType string
+
+const (
+	BinderType2x2     BinderType = "2x2"
+	BinderType3x3     BinderType = "3x3"
+	BinderType3x4     BinderType = "3x4"
+	BinderType4Pocket BinderType = "4-pocket"
+
+	DefaultBinderType = BinderType3x3
+)
+
+type PageLayout struct {
+	Rows    int `json:"rows" bson:"rows"`
+	Columns int `json:"columns" bson:"columns"`
+}
+
+func (l PageLayout) Slots() int {
+	return l.Rows * l.Columns
+}
+
+var BinderLayouts = map[BinderType]PageLayout{
+	BinderType2x2:     {Rows: 2, Columns: 2},
+	BinderType3x3:     {Rows: 3, Columns: 3},
+	BinderType3x4:     {Rows: 3, Columns: 4},
+	BinderType4Pocket: {Rows: 1, Columns: 4},
+}
+
+// Layout returns the page layout of the binder, falling back to the default
+// layout for binders created before types were enforced.
+func (b Binder) Layout() PageLayout {
+	if l, ok := BinderLayouts[b.Type]; ok {
+		return l
+	}
+
+	return BinderLayouts[DefaultBinderType]
+}
+
+// SlotPosition is a zero based page number and a zero based pocket index,
+// counted left to right and top to bottom within the page.
+type SlotPosition struct {
+	Page int `json:"page" bson:"page"`
+	Slot int `json:"slot" bson:"slot"`
+}
+
+type CardCondition string
+
+const (
+	CardConditionMint      CardCondition = "mint"
+	CardConditionNearMint  CardCondition = "near_mint"
+	CardConditionGood      CardCondition = "good"
+	CardConditionBent      CardCondition = "bent"
+	CardConditionScratched CardCondition = "scratched"
+	CardConditionDamaged   CardCondition = "damaged"
+)
+
+var CardConditions = map[CardCondition]struct{}{
+	CardConditionMint:      {},
+	CardConditionNearMint:  {},
+	CardConditionGood:      {},
+	CardConditionBent:      {},
+	CardConditionScratched: {},
+	CardConditionDamaged:   {},
+}
+
+type BinderEntry struct {
+	CardID      string        `json:"card_id" bson:"card_id"`
+	Quantity    int           `json:"quantity" bson:"quantity"`
+	Condition   CardCondition `json:"condition" bson:"condition,omitempty"`
+	PricePaid   *float64      `json:"price_paid" bson:"price_paid,omitempty"`
+	Currency    string        `json:"currency" bson:"currency,omitempty"`
+	PurchasedAt *time.Time    `json:"purchased_at" bson:"purchased_at,omitempty"`
+	Source      string        `json:"source" bson:"source,omitempty"`
+	AddedAt     time.Time     `json:"added_at" bson:"added_at"`
+	Position    *SlotPosition `json:"position" bson:"position,omitempty"`
+}
+
+// BinderPlaceholder reserves a pocket for a card the binder is meant to hold.
+type BinderPlaceholder struct {
+	CardID   string       `json:"card_id" bson:"card_id"`
+	Position SlotPosition `json:"position" bson:"position"`
+}
+
+// BinderCard is a binder entry with its card. Owned is false for placeholder
+// pockets of cards the binder does not hold yet.
+type BinderCard struct {
+	BinderEntry
+	Card  ExpandedCard `json:"card"`
+	Owned bool         `json:"owned"`
+}
+
+// BinderTemplate describes a binder to generate from a collection.
+type BinderTemplate struct {
+	CollectionID string           `json:"collection_id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	ImageURL     string           `json:"image_url"`
+	Type         BinderType       `json:"type"`
+	Visibility   BinderVisibility `json:"visibility"`
+}
+
+type BinderPage struct {
+	Number int           `json:"number"`
+	Slots  []*BinderCard `json:"slots"`
+}
+
+type BinderPages struct {
+	Type   BinderType   `json:"type"`
+	Layout PageLayout   `json:"layout"`
+	Pages  []BinderPage `json:"pages"`
+	Loose  []BinderCard `json:"loose"`
+}
+
+// MigrateLegacyCardIDs moves card ids stored in the legacy CardIDs field into
+// Entries, one copy each.
+func (b *Binder) MigrateLegacyCardIDs() {
+	if b.CardIDs == nil {
+		return
+	}
+
+	for _, id := range *b.CardIDs {
+		if b.HasCard(id) {
+			continue
+		}
+		b.Entries = append(b.Entries, BinderEntry{CardID: id, Quantity: 1})
+	}
+
+	b.CardIDs = nil
+}
+
+// Placeholder returns the pocket reserved for cardID, if any.
+func (b Binder) Placeholder(cardID string) *SlotPosition {
+	for _, p := range b.Placeholders {
+		if p.CardID == cardID {
+			pos := p.Position
+			return &pos
+		}
+	}
+
+	return nil
+}
+
+func (b Binder) IsSmart() bool {
+	return len(b.Query) > 0
+}
+
+// RoleOf returns the role userID holds on the binder, or an empty role.
+func (b Binder) RoleOf(userID string) BinderRole {
+	if userID == "" {
+		return ""
+	}
+
+	if b.UserID == userID {
+		return BinderRoleOwner
+	}
+
+	for _, m := range b.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+
+	return ""
+}
+
+func (b *Binder) HasShareToken(token string) bool {
+	for _, t := range b.ShareTokens {
+		if t.Token == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *Binder) HasCard(cardID string) bool {
+	for _, e := range b.Entries {
+		if e.CardID == cardID {
+			return true
+		}
+	}
+
+	return false
 }
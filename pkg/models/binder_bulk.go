@@ -0,0 +1,36 @@
+package models
+
+type BulkOp string
+
+const (
+	BulkOpAdd    BulkOp = "add"
+	BulkOpRemove BulkOp = "remove"
+	BulkOpMove   BulkOp = "move"
+)
+
+// BulkOperation is one item of a bulk binder change. Add uses every entry
+// field; remove only the card id; move places the card at Position, or takes
+// it out of its pocket when Position is empty.
+type BulkOperation struct {
+	Op BulkOp `json:"op"`
+	BinderEntry
+}
+
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Op     BulkOp `json:"op"`
+	CardID string `json:"card_id"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkReport struct {
+	Applied int          `json:"applied"`
+	Failed  int          `json:"failed"`
+	Results []BulkResult `json:"results"`
+}
+
+type TransferRequest struct {
+	ToBinderID string   `json:"to_binder_id"`
+	CardIDs    []string `json:"card_ids"`
+}
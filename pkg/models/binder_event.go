@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type BinderEventAction string
+
+const (
+	BinderEventCreate     BinderEventAction = "create"
+	BinderEventUpdate     BinderEventAction = "update"
+	BinderEventAddCard    BinderEventAction = "add_card"
+	BinderEventUpdateCard BinderEventAction = "update_card"
+	BinderEventRemoveCard BinderEventAction = "remove_card"
+	BinderEventLayout     BinderEventAction = "layout"
+	BinderEventConvert    BinderEventAction = "convert_to_static"
+	BinderEventImport     BinderEventAction = "import"
+	BinderEventBulk       BinderEventAction = "bulk"
+	BinderEventTransfer   BinderEventAction = "transfer"
+	BinderEventDelete     BinderEventAction = "delete"
+	BinderEventUndo       BinderEventAction = "undo"
+	BinderEventRestore    BinderEventAction = "restore"
+	BinderEventDetach     BinderEventAction = "detach"
+)
+
+// BinderEvent is one entry of the append-only binder history. Before and
+// After are full snapshots; a nil snapshot means the binder did not exist.
+type BinderEvent struct {
+	ID       string            `json:"id" bson:"_id"`
+	BinderID string            `json:"binder_id" bson:"binder_id" indexed:"true"`
+	UserID   string            `json:"user_id" bson:"user_id"`
+	Action   BinderEventAction `json:"action" bson:"action"`
+	At       time.Time         `json:"at" bson:"at" indexed:"true"`
+	Before   *Binder           `json:"before" bson:"before"`
+	After    *Binder           `json:"after" bson:"after"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// BinderExportRow is one binder entry flattened with readable catalog names,
+// the shape used for CSV and JSON export and import.
+type BinderExportRow struct {
+	CardID      string        `json:"card_id"`
+	Name        string        `json:"name"`
+	ShortName   string        `json:"short_name"`
+	Group       string        `json:"group"`
+	Idols       []string      `json:"idols"`
+	Collection  string        `json:"collection"`
+	Type        CardType      `json:"type"`
+	Quantity    int           `json:"quantity"`
+	Condition   CardCondition `json:"condition"`
+	PricePaid   *float64      `json:"price_paid"`
+	Currency    string        `json:"currency"`
+	PurchasedAt *time.Time    `json:"purchased_at"`
+	Source      string        `json:"source"`
+	Page        *int          `json:"page"`
+	Slot        *int          `json:"slot"`
+}
+
+type ImportIssue struct {
+	Row    int             `json:"row"`
+	Reason string          `json:"reason"`
+	Data   BinderExportRow `json:"data"`
+}
+
+type ImportReport struct {
+	Imported  int           `json:"imported"`
+	Skipped   []ImportIssue `json:"skipped"`
+	Unmatched []ImportIssue `json:"unmatched"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// BinderFolder groups a user's binders. Folders nest through ParentID; an
+// empty ParentID puts the folder at the top of the shelf.
+type BinderFolder struct {
+	ID       string `json:"id" bson:"_id"`
+	UserID   string `json:"user_id" bson:"user_id" indexed:"true"`
+	Name     string `json:"name" bson:"name"`
+	ParentID string `json:"parent_id" bson:"parent_id" indexed:"true"`
+}
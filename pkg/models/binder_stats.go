@@ -0,0 +1,27 @@
+package models
+
+type StatsBucket struct {
+	Key    string `json:"key" bson:"key"`
+	Label  string `json:"label" bson:"label"`
+	Cards  int    `json:"cards" bson:"cards"`
+	Copies int    `json:"copies" bson:"copies"`
+}
+
+type ValueTotal struct {
+	Currency string  `json:"currency" bson:"currency"`
+	Total    float64 `json:"total" bson:"total"`
+	Cards    int     `json:"cards" bson:"cards"`
+}
+
+// BinderStats aggregates binder contents. Cards counts distinct cards,
+// Copies counts every copy and Duplicates the copies beyond the first.
+type BinderStats struct {
+	Cards        int           `json:"cards" bson:"cards"`
+	Copies       int           `json:"copies" bson:"copies"`
+	Duplicates   int           `json:"duplicates" bson:"duplicates"`
+	ByGroup      []StatsBucket `json:"by_group" bson:"by_group"`
+	ByIdol       []StatsBucket `json:"by_idol" bson:"by_idol"`
+	ByCollection []StatsBucket `json:"by_collection" bson:"by_collection"`
+	ByType       []StatsBucket `json:"by_type" bson:"by_type"`
+	Value        []ValueTotal  `json:"value,omitempty" bson:"value"`
+}
@@ -1,26 +1,59 @@
 package models
 
 type Card struct {
-	ID           string   `json:"id" bson:"_id"`
-	Name         string   `json:"name" bson:"name" indexed:"true"`
-	ShortName    string   `json:"short_name" bson:"short_name"`
-	Type         CardType `json:"type" bson:"type" indexed:"true"`
-	ImageUrl     string   `json:"image_url" bson:"image_url"`
-	GroupID      string   `json:"group_id" bson:"group_id" indexed:"true"`
-	CollectionID string   `json:"collection_id" bson:"collection_id" indexed:"true"`
-	IdolIDs      []string `json:"idol_ids" bson:"idol_ids" indexed:"true"`
+	ID           string         `json:"id" bson:"_id"`
+	Name         string         `json:"name" bson:"name" indexed:"true"`
+	Names        LocalizedNames `json:"names" bson:"names"`
+	ShortName    string         `json:"short_name" bson:"short_name"`
+	Type         CardType       `json:"type" bson:"type" indexed:"true"`
+	ImageUrl     string         `json:"image_url" bson:"image_url"`
+	GroupID      string         `json:"group_id" bson:"group_id" indexed:"true"`
+	CollectionID string         `json:"collection_id" bson:"collection_id" indexed:"true"`
+	IdolIDs      []string       `json:"idol_ids" bson:"idol_ids" indexed:"true"`
+	BaseCardID   string         `json:"base_card_id,omitempty" bson:"base_card_id,omitempty" indexed:"true"`
+	Variant      *CardVariant   `json:"variant,omitempty" bson:"variant,omitempty"`
+	Contributors []string       `json:"contributors,omitempty" bson:"contributors,omitempty"`
+}
+
+// IsVariant reports whether the card is a variant of another card.
+func (c Card) IsVariant() bool {
+	return c.BaseCardID != ""
+}
+
+type VariantKind string
+
+const (
+	VariantKindVersion VariantKind = "version"
+	VariantKindReprint VariantKind = "reprint"
+	VariantKindFinish  VariantKind = "finish"
+	VariantKindStore   VariantKind = "store"
+)
+
+var VariantKinds = []VariantKind{
+	VariantKindVersion,
+	VariantKindReprint,
+	VariantKindFinish,
+	VariantKindStore,
+}
+
+// CardVariant describes how a variant differs from its base card, e.g. a
+// finish variant labelled Hologram or a store variant labelled Weverse Shop.
+type CardVariant struct {
+	Kind  VariantKind `json:"kind" bson:"kind"`
+	Label string      `json:"label" bson:"label"`
 }
 
 type EnrichedCard struct {
-	ID         string     `json:"id" bson:"_id"`
-	Name       string     `json:"name" bson:"name"`
-	ShortName  string     `json:"short_name" bson:"short_name"`
-	Type       CardType   `json:"type" bson:"type"`
-	FmtType    string     `json:"fmt_type" bson:"fmt_type"`
-	ImageUrl   string     `json:"image_url" bson:"image_url"`
-	Group      Group      `json:"group" bson:"group"`
-	Collection Collection `json:"collection" bson:"collection"`
-	Idols      []Idol     `json:"idols" bson:"idols"`
+	ID         string         `json:"id" bson:"_id"`
+	Name       string         `json:"name" bson:"name"`
+	Names      LocalizedNames `json:"names" bson:"names"`
+	ShortName  string         `json:"short_name" bson:"short_name"`
+	Type       CardType       `json:"type" bson:"type"`
+	FmtType    string         `json:"fmt_type" bson:"fmt_type"`
+	ImageUrl   string         `json:"image_url" bson:"image_url"`
+	Group      Group          `json:"group" bson:"group"`
+	Collection Collection     `json:"collection" bson:"collection"`
+	Idols      []Idol         `json:"idols" bson:"idols"`
 }
 
 type CardType string
@@ -36,6 +69,18 @@ const (
 	CardTypeLuckyDraw CardType = "lucky_draw"
 )
 
+// CardTypes lists every card type in display order.
+var CardTypes = []CardType{
+	CardTypeRegular,
+	CardTypePOB,
+	CardTypeEvent,
+	CardTypeSpecial,
+	CardTypeTrading,
+	CardTypeLimited,
+	CardTypeMerch,
+	CardTypeLuckyDraw,
+}
+
 var ShortTypesMap = map[CardType]string{
 	CardTypeRegular:   "R",
 	CardTypePOB:       "POB",
@@ -46,3 +91,12 @@ var ShortTypesMap = map[CardType]string{
 	CardTypeMerch:     "Merch",
 	CardTypeLuckyDraw: "LD",
 }
+
+// ExpandedCard is a card with the related resources a listing asked for.
+// Relations that were not asked for are left out.
+type ExpandedCard struct {
+	Card       `bson:",inline"`
+	Group      *Group      `json:"group,omitempty" bson:"-"`
+	Idols      []Idol      `json:"idols,omitempty" bson:"-"`
+	Collection *Collection `json:"collection,omitempty" bson:"-"`
+}
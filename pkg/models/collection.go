@@ -1,7 +1,8 @@
 package models
 
 type Collection struct {
-	ID      string `json:"id" bson:"_id"`
-	Name    string `json:"name" bson:"name"`
-	GroupID string `json:"group_id" bson:"group_id"`
+	ID      string         `json:"id" bson:"_id"`
+	Name    string         `json:"name" bson:"name" indexed:"true"`
+	Names   LocalizedNames `json:"names" bson:"names"`
+	GroupID string         `json:"group_id" bson:"group_id" indexed:"true"`
 }
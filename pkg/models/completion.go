@@ -0,0 +1,32 @@
+package models
+
+type CompletionBreakdown struct {
+	Key        string  `json:"key"`
+	Label      string  `json:"label"`
+	Owned      int     `json:"owned"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+// VariantCounting decides how card variants take part in completion: each
+// variant as a card of its own, owning any variant completing its base card,
+// or only base cards counting.
+type VariantCounting string
+
+const (
+	VariantCountingEach   VariantCounting = "each"
+	VariantCountingAny    VariantCounting = "any"
+	VariantCountingIgnore VariantCounting = "ignore"
+)
+
+type Completion struct {
+	Collection   Collection            `json:"collection"`
+	Variants     VariantCounting       `json:"variants"`
+	Owned        int                   `json:"owned"`
+	Total        int                   `json:"total"`
+	Percentage   float64               `json:"percentage"`
+	OwnedCards   []Card                `json:"owned_cards"`
+	MissingCards []Card                `json:"missing_cards"`
+	ByIdol       []CompletionBreakdown `json:"by_idol"`
+	ByType       []CompletionBreakdown `json:"by_type"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// DeletionReport lists what deleting a catalog document removes, or would
+// remove on a dry run: the document itself and, with a cascade, everything
+// depending on it. UpdatedCards lose a deleted idol but keep their other
+// idols; Binders hold deleted cards that are taken out of them.
+type DeletionReport struct {
+	Resource     string   `json:"resource"`
+	ID           string   `json:"id"`
+	Cascade      bool     `json:"cascade"`
+	DryRun       bool     `json:"dry_run"`
+	Groups       []string `json:"groups"`
+	Idols        []string `json:"idols"`
+	Collections  []string `json:"collections"`
+	Cards        []string `json:"cards"`
+	UpdatedCards []string `json:"updated_cards"`
+	Binders      []string `json:"binders"`
+}
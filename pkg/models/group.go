@@ -1,7 +1,8 @@
 package models
 
 type Group struct {
-	ID       string `json:"id" bson:"_id"`
-	Name     string `json:"name" bson:"name"`
-	ImageURL string `json:"image_url" bson:"image_url"`
+	ID       string         `json:"id" bson:"_id"`
+	Name     string         `json:"name" bson:"name" indexed:"true"`
+	Names    LocalizedNames `json:"names" bson:"names"`
+	ImageURL string         `json:"image_url" bson:"image_url"`
 }
@@ -1,8 +1,10 @@
 package models
 
 type Idol struct {
-	ID        string `json:"id" bson:"_id"`
-	StageName string `json:"stage_name" bson:"stage_name"`
-	Name      string `json:"name" bson:"name"`
-	GroupID   string `json:"group_id" bson:"group_id"`
+	ID         string         `json:"id" bson:"_id"`
+	StageName  string         `json:"stage_name" bson:"stage_name" indexed:"true"`
+	StageNames LocalizedNames `json:"stage_names" bson:"stage_names"`
+	Name       string         `json:"name" bson:"name" indexed:"true"`
+	Names      LocalizedNames `json:"names" bson:"names"`
+	GroupID    string         `json:"group_id" bson:"group_id" indexed:"true"`
 }
@@ -0,0 +1,17 @@
+package models
+
+// IntegrityIssue is a reference from one document to another that no longer
+// exists, e.g. the group_id of an idol whose group was deleted.
+type IntegrityIssue struct {
+	Resource string `json:"resource"`
+	ID       string `json:"id"`
+	Field    string `json:"field"`
+	Missing  string `json:"missing"`
+}
+
+// IntegrityReport lists the broken references found in the database and,
+// after a repair, how many documents were fixed.
+type IntegrityReport struct {
+	Issues   []IntegrityIssue `json:"issues"`
+	Repaired int64            `json:"repaired"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// LocalizedNames holds the Korean, English and Japanese forms of a name.
+type LocalizedNames struct {
+	Ko string `json:"ko,omitempty" bson:"ko,omitempty"`
+	En string `json:"en,omitempty" bson:"en,omitempty"`
+	Ja string `json:"ja,omitempty" bson:"ja,omitempty"`
+}
+
+// All returns the non empty names.
+func (n LocalizedNames) All() []string {
+	all := make([]string, 0, 3)
+	for _, name := range []string{n.Ko, n.En, n.Ja} {
+		if name != "" {
+			all = append(all, name)
+		}
+	}
+
+	return all
+}
@@ -0,0 +1,11 @@
+package models
+
+// SearchResult is a card ranked by a search. Matched counts the query words
+// found, Score how well they matched. Results grouped by base card list the
+// matching variants of Card.
+type SearchResult struct {
+	Card     Card    `json:"card"`
+	Matched  int     `json:"matched"`
+	Score    float64 `json:"score"`
+	Variants []Card  `json:"variants,omitempty"`
+}
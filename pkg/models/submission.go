@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type SubmissionKind string
+
+const (
+	SubmissionNewCard    SubmissionKind = "new_card"
+	SubmissionCorrection SubmissionKind = "correction"
+	SubmissionImage      SubmissionKind = "image"
+)
+
+var SubmissionKinds = []SubmissionKind{
+	SubmissionNewCard,
+	SubmissionCorrection,
+	SubmissionImage,
+}
+
+type SubmissionStatus string
+
+const (
+	SubmissionPending          SubmissionStatus = "pending"
+	SubmissionChangesRequested SubmissionStatus = "changes_requested"
+	SubmissionApproved         SubmissionStatus = "approved"
+	SubmissionRejected         SubmissionStatus = "rejected"
+)
+
+var SubmissionStatuses = []SubmissionStatus{
+	SubmissionPending,
+	SubmissionChangesRequested,
+	SubmissionApproved,
+	SubmissionRejected,
+}
+
+// Submission is a change to the card catalog proposed by a user. Card holds
+// the proposed card: the whole card for a new card, otherwise the target card
+// with the proposal applied, of which only Fields are part of the change.
+// CardID is the target card, or the created card once a new card is
+// approved.
+type Submission struct {
+	ID         string           `json:"id" bson:"_id"`
+	UserID     string           `json:"user_id" bson:"user_id" indexed:"true"`
+	Kind       SubmissionKind   `json:"kind" bson:"kind" indexed:"true"`
+	Status     SubmissionStatus `json:"status" bson:"status" indexed:"true"`
+	CardID     string           `json:"card_id,omitempty" bson:"card_id,omitempty" indexed:"true"`
+	Card       Card             `json:"card" bson:"card"`
+	Fields     []string         `json:"fields" bson:"fields"`
+	Note       string           `json:"note,omitempty" bson:"note,omitempty"`
+	ReviewerID string           `json:"reviewer_id,omitempty" bson:"reviewer_id,omitempty"`
+	ReviewNote string           `json:"review_note,omitempty" bson:"review_note,omitempty"`
+	CreatedAt  time.Time        `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at" bson:"updated_at"`
+	ReviewedAt *time.Time       `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	Version    int              `json:"version" bson:"version"`
+}
+
+// SubmissionProposal is what a user sends to propose a change. Card is read
+// as a partial card for corrections and images: fields left out keep their
+// current value.
+type SubmissionProposal struct {
+	Kind   SubmissionKind  `json:"kind"`
+	CardID string          `json:"card_id"`
+	Card   json.RawMessage `json:"card"`
+	Note   string          `json:"note"`
+}
+
+type SubmissionReview struct {
+	Note string `json:"note"`
+}
+
+// FieldDiff is a card field as it is in the catalog and as a submission
+// would make it. Current is null for new cards.
+type FieldDiff struct {
+	Field    string          `json:"field"`
+	Current  json.RawMessage `json:"current"`
+	Proposed json.RawMessage `json:"proposed"`
+}
+
+type SubmissionDiff struct {
+	Submission Submission  `json:"submission"`
+	Fields     []FieldDiff `json:"fields"`
+}
@@ -6,4 +6,29 @@ type User struct {
 	Username string `json:"username" bson:"username"`
 	ImageURL string `json:"image_url" bson:"image_url"`
 	Password string `json:"password" bson:"password"`
+	Role     Role   `json:"role" bson:"role"`
+}
+
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleCurator Role = "curator"
+	RoleMember  Role = "member"
+)
+
+var Roles = []Role{
+	RoleAdmin,
+	RoleCurator,
+	RoleMember,
+}
+
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if role == r {
+			return true
+		}
+	}
+
+	return false
 }